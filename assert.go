@@ -0,0 +1,367 @@
+package ghz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+)
+
+// Assertion is a check of the outcome of every call. A call failing any of
+// its assertions is counted as an error, and every failed assertion is
+// counted separately in the report. All the conditions set are checked,
+// the ones on the response only for calls ending with an OK status.
+type Assertion struct {
+	// Name of the assertion in the report. Defaults to a description of the conditions.
+	Name string `json:"name,omitempty"`
+
+	// The status codes the call is expected to end with, such as OK or NotFound.
+	// A call ending with one of them is not counted as an error even if it is not OK.
+	Status []string `json:"status,omitempty"`
+
+	// Path of a field of the response, such as $.items[0].name, with the
+	// fields named as in the proto. The field must be present.
+	Path string `json:"path,omitempty"`
+
+	// The value the field at the path is expected to equal
+	Equals interface{} `json:"equals,omitempty"`
+
+	// A regular expression the field at the path, or the whole response
+	// as JSON without a path, is expected to match
+	Matches string `json:"matches,omitempty"`
+
+	// The number of messages expected on a server stream
+	Messages *int `json:"messages,omitempty"`
+}
+
+// assertion is an Assertion prepared for checking the calls
+type assertion struct {
+	*Assertion
+
+	name    string
+	path    []interface{}
+	matches *regexp.Regexp
+}
+
+// newAssertions validates the assertions and prepares them for checking the calls
+func newAssertions(in []Assertion) ([]*assertion, error) {
+	res := make([]*assertion, len(in))
+	for i := range in {
+		a, err := newAssertion(&in[i])
+		if err != nil {
+			return nil, fmt.Errorf("assertion %d: %v", i+1, err)
+		}
+		res[i] = a
+	}
+	return res, nil
+}
+
+func newAssertion(in *Assertion) (*assertion, error) {
+	if len(in.Status) == 0 && in.Path == "" && in.Matches == "" && in.Messages == nil {
+		return nil, errors.New("status, path, matches or messages is required")
+	}
+
+	for _, st := range in.Status {
+		if !isStatusCode(st) {
+			return nil, fmt.Errorf("unknown status code %q", st)
+		}
+	}
+
+	if in.Equals != nil && in.Path == "" {
+		return nil, errors.New("equals requires a path")
+	}
+
+	if in.Messages != nil && *in.Messages < 0 {
+		return nil, errors.New("messages must not be negative")
+	}
+
+	a := &assertion{Assertion: in, name: in.Name}
+
+	if in.Path != "" {
+		path, err := parsePath(in.Path)
+		if err != nil {
+			return nil, err
+		}
+		a.path = path
+	}
+
+	if in.Matches != "" {
+		re, err := regexp.Compile(in.Matches)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matches: %v", err)
+		}
+		a.matches = re
+	}
+
+	if a.name == "" {
+		a.name = a.describe()
+	}
+
+	return a, nil
+}
+
+// describe returns a description of the conditions of the assertion
+func (a *assertion) describe() string {
+	var conds []string
+	if len(a.Status) > 0 {
+		conds = append(conds, "status in ["+strings.Join(a.Status, " ")+"]")
+	}
+
+	target := a.Path
+	if target == "" {
+		target = "response"
+	}
+
+	if a.Equals != nil {
+		equals, _ := json.Marshal(a.Equals)
+		conds = append(conds, fmt.Sprintf("%s == %s", target, equals))
+	}
+
+	if a.Matches != "" {
+		conds = append(conds, fmt.Sprintf("%s =~ %q", target, a.Matches))
+	}
+
+	if a.Path != "" && a.Equals == nil && a.Matches == "" {
+		conds = append(conds, a.Path+" exists")
+	}
+
+	if a.Messages != nil {
+		conds = append(conds, fmt.Sprintf("messages == %d", *a.Messages))
+	}
+
+	return strings.Join(conds, ", ")
+}
+
+// callOutcome is the outcome of a call checked by the assertions
+type callOutcome struct {
+	status   string
+	response proto.Message
+	messages int
+
+	// the response decoded once for the assertions on its fields
+	decoded   interface{}
+	decodeOK  bool
+	decodeErr error
+}
+
+func (o *callOutcome) decode() (interface{}, error) {
+	if !o.decodeOK {
+		o.decoded, o.decodeErr = messageToMap(o.response)
+		o.decodeOK = true
+	}
+	return o.decoded, o.decodeErr
+}
+
+// check returns whether the outcome of the call meets the conditions
+func (a *assertion) check(o *callOutcome) bool {
+	if len(a.Status) > 0 && !containsString(a.Status, o.status) {
+		return false
+	}
+
+	if o.status != codes.OK.String() {
+		return true
+	}
+
+	if a.Messages != nil && *a.Messages != o.messages {
+		return false
+	}
+
+	if a.path == nil && a.matches == nil {
+		return true
+	}
+
+	value, err := o.decode()
+	if err != nil || value == nil {
+		return false
+	}
+
+	if a.path != nil {
+		var ok bool
+		if value, ok = lookupPath(value, a.path); !ok {
+			return false
+		}
+	}
+
+	if a.Equals != nil && !valuesEqual(value, a.Equals) {
+		return false
+	}
+
+	if a.matches != nil {
+		s, ok := value.(string)
+		if !ok {
+			b, err := json.Marshal(value)
+			if err != nil {
+				return false
+			}
+			s = string(b)
+		}
+
+		if !a.matches.MatchString(s) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkAssertions returns the names of the assertions the outcome of the
+// call fails, and whether the call ended with an expected status
+func checkAssertions(assertions []*assertion, o *callOutcome) ([]string, bool) {
+	var failed []string
+	expected := false
+	for _, a := range assertions {
+		if !a.check(o) {
+			failed = append(failed, a.name)
+		} else if len(a.Status) > 0 {
+			expected = true
+		}
+	}
+	return failed, expected && len(failed) == 0
+}
+
+// parsePath parses a path such as $.items[0].name into the
+// field names and indexes of the elements along it
+func parsePath(path string) ([]interface{}, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	if p != "" && p[0] != '.' && p[0] != '[' {
+		p = "." + p
+	}
+
+	var res []interface{}
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q: empty field name", path)
+			}
+			res = append(res, p[:end])
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", path)
+			}
+			i, err := strconv.Atoi(p[1:end])
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid path %q: invalid index %q", path, p[1:end])
+			}
+			res = append(res, i)
+			p = p[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("invalid path %q: no fields", path)
+	}
+
+	return res, nil
+}
+
+// lookupPath returns the value at the path within the decoded JSON value
+func lookupPath(value interface{}, path []interface{}) (interface{}, bool) {
+	for _, elem := range path {
+		switch key := elem.(type) {
+		case string:
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if value, ok = obj[key]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := value.([]interface{})
+			if !ok || key >= len(arr) {
+				return nil, false
+			}
+			value = arr[key]
+		}
+	}
+	return value, true
+}
+
+// valuesEqual compares a decoded JSON value of the response to the expected
+// one. Scalars are compared as text, as 64-bit integers are strings in JSON.
+func valuesEqual(actual, expected interface{}) bool {
+	if reflect.DeepEqual(actual, expected) {
+		return true
+	}
+
+	switch actual.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+
+	switch expected.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+
+	return fmt.Sprint(actual) == fmt.Sprint(expected)
+}
+
+func isStatusCode(s string) bool {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if c.String() == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// heldResult holds back the result of a call with assertions from the
+// stats handler, so that it is reported once the response has been checked
+type heldResult struct {
+	mu       sync.Mutex
+	result   *callResult
+	released bool
+}
+
+type heldResultKey struct{}
+
+// hold keeps the result, returning false if it has already been
+// released and the result is to be reported as is
+func (h *heldResult) hold(res *callResult) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.released {
+		return false
+	}
+
+	h.result = res
+	return true
+}
+
+// release returns the result held, nil if the call has not ended yet
+// in which case the stats handler reports it as is
+func (h *heldResult) release() *callResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.released = true
+	return h.result
+}
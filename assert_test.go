@@ -0,0 +1,119 @@
+package ghz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz/internal/helloworld"
+)
+
+func TestAssertion_new(t *testing.T) {
+	three := 3
+	negative := -1
+
+	var tests = []struct {
+		name     string
+		in       Assertion
+		expected string
+		err      string
+	}{
+		{"status", Assertion{Status: []string{"OK", "NotFound"}}, "status in [OK NotFound]", ""},
+		{"equals", Assertion{Path: "$.message", Equals: "Hello bob"}, `$.message == "Hello bob"`, ""},
+		{"matches", Assertion{Path: "message", Matches: ".+"}, `message =~ ".+"`, ""},
+		{"matches response", Assertion{Matches: "Hello"}, `response =~ "Hello"`, ""},
+		{"exists", Assertion{Path: "$.items[0].name"}, "$.items[0].name exists", ""},
+		{"messages", Assertion{Status: []string{"OK"}, Messages: &three}, "status in [OK], messages == 3", ""},
+		{"name", Assertion{Name: "non empty", Path: "$.message", Matches: ".+"}, "non empty", ""},
+		{"empty", Assertion{}, "", "status, path, matches or messages is required"},
+		{"unknown status", Assertion{Status: []string{"Ok"}}, "", `unknown status code "Ok"`},
+		{"equals without path", Assertion{Matches: "a", Equals: "a"}, "", "equals requires a path"},
+		{"invalid matches", Assertion{Matches: "("}, "", "invalid matches: error parsing regexp: missing closing ): `(`"},
+		{"invalid path", Assertion{Path: "$.items[a]"}, "", `invalid path "$.items[a]": invalid index "a"`},
+		{"negative messages", Assertion{Messages: &negative}, "", "messages must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := newAssertion(&tt.in)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, a.name)
+		})
+	}
+}
+
+func TestAssertion_parsePath(t *testing.T) {
+	var tests = []struct {
+		in       string
+		expected []interface{}
+		err      bool
+	}{
+		{"$.message", []interface{}{"message"}, false},
+		{"message", []interface{}{"message"}, false},
+		{"$.items[1].name", []interface{}{"items", 1, "name"}, false},
+		{"items[0][2]", []interface{}{"items", 0, 2}, false},
+		{"$", nil, true},
+		{"$.items..name", nil, true},
+		{"$.items[0", nil, true},
+		{"$.items[-1]", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			path, err := parsePath(tt.in)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, path)
+		})
+	}
+}
+
+func TestAssertion_check(t *testing.T) {
+	two := 2
+
+	newOutcome := func(status, message string) *callOutcome {
+		return &callOutcome{status: status, response: &helloworld.HelloReply{Message: message}, messages: 2}
+	}
+
+	var tests = []struct {
+		name     string
+		in       Assertion
+		outcome  *callOutcome
+		expected bool
+	}{
+		{"status", Assertion{Status: []string{"OK"}}, newOutcome("OK", "Hello"), true},
+		{"other status", Assertion{Status: []string{"OK"}}, newOutcome("Unavailable", ""), false},
+		{"expected error status", Assertion{Status: []string{"NotFound"}}, newOutcome("NotFound", ""), true},
+		{"equals", Assertion{Path: "$.message", Equals: "Hello bob"}, newOutcome("OK", "Hello bob"), true},
+		{"not equals", Assertion{Path: "$.message", Equals: "Hello bob"}, newOutcome("OK", "Hello"), false},
+		{"matches", Assertion{Path: "$.message", Matches: "^Hello .+"}, newOutcome("OK", "Hello bob"), true},
+		{"empty body", Assertion{Path: "$.message", Matches: ".+"}, newOutcome("OK", ""), false},
+		{"matches response", Assertion{Matches: `"message":"Hello`}, newOutcome("OK", "Hello bob"), true},
+		{"missing field", Assertion{Path: "$.name"}, newOutcome("OK", "Hello"), false},
+		{"messages", Assertion{Messages: &two}, newOutcome("OK", "Hello"), true},
+		{"response of error not checked", Assertion{Path: "$.message", Equals: "Hello"}, newOutcome("Unavailable", ""), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := newAssertion(&tt.in)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, a.check(tt.outcome))
+		})
+	}
+
+	t.Run("values", func(t *testing.T) {
+		assert.True(t, valuesEqual("42", float64(42)))
+		assert.True(t, valuesEqual(true, true))
+		assert.True(t, valuesEqual([]interface{}{"a"}, []interface{}{"a"}))
+		assert.False(t, valuesEqual(map[string]interface{}{}, "map[]"))
+	})
+}
@@ -3,7 +3,10 @@ package ghz
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
+	"sync"
 	"text/template"
+	"text/template/parse"
 	"time"
 
 	"github.com/jhump/protoreflect/desc"
@@ -21,6 +24,18 @@ type callTemplateData struct {
 	IsServerStreaming  bool   // whether this call is server streaming
 	Timestamp          string // timestamp of the call in RFC3339 format
 	TimestampUnix      int64  // timestamp of the call as unix time
+
+	WorkerID            int   // 1-based id of the worker making the call, 0 if not made by a worker
+	WorkerRequestNumber int64 // incremented request number within the worker
+
+	// the values of the CSV row for the call by column name
+	Row map[string]string
+
+	// the request and response of the previous steps of the sequence by step name
+	Steps map[string]map[string]interface{}
+
+	// the functions of the worker making the call
+	worker *templateWorker
 }
 
 // newCallTemplateData returns new call template data
@@ -41,22 +56,25 @@ func newCallTemplateData(mtd *desc.MethodDescriptor, reqNum int64) *callTemplate
 	}
 }
 
-func (td *callTemplateData) execute(data string) (*bytes.Buffer, error) {
-	t := template.Must(template.New("call_template_data").Parse(data))
-	var tpl bytes.Buffer
-	err := t.Execute(&tpl, td)
-	return &tpl, err
+func (td *callTemplateData) executeData(data string) (interface{}, error) {
+	input, err := parseCallTemplate(data).execute(td)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalData(input)
 }
 
-func (td *callTemplateData) executeData(data string) (interface{}, error) {
-	input := []byte(data)
-	tpl, err := td.execute(data)
-	if err == nil {
-		input = tpl.Bytes()
+func (td *callTemplateData) executeMetadata(metadata string) (*map[string]string, error) {
+	input, err := parseCallTemplate(metadata).execute(td)
+	if err != nil {
+		return nil, err
 	}
+	return unmarshalMetadata(input)
+}
 
+func unmarshalData(input []byte) (interface{}, error) {
 	var dataMap interface{}
-	err = json.Unmarshal(input, &dataMap)
+	err := json.Unmarshal(input, &dataMap)
 	if err != nil {
 		return nil, err
 	}
@@ -64,18 +82,81 @@ func (td *callTemplateData) executeData(data string) (interface{}, error) {
 	return dataMap, nil
 }
 
-func (td *callTemplateData) executeMetadata(metadata string) (*map[string]string, error) {
-	input := []byte(metadata)
-	tpl, err := td.execute(metadata)
-	if err == nil {
-		input = tpl.Bytes()
-	}
-
+func unmarshalMetadata(input []byte) (*map[string]string, error) {
 	var mdMap map[string]string
-	err = json.Unmarshal(input, &mdMap)
+	err := json.Unmarshal(input, &mdMap)
 	if err != nil {
 		return nil, err
 	}
 
 	return &mdMap, nil
 }
+
+// callTemplate is call data or metadata parsed once,
+// so that only the execution is left for every call
+type callTemplate struct {
+	text string
+
+	// nil when the text has no template actions
+	tmpl *template.Template
+
+	// the template with the functions of each worker by *templateWorker
+	workers sync.Map
+}
+
+func parseCallTemplate(text string) *callTemplate {
+	ct := &callTemplate{text: text}
+	if !strings.Contains(text, "{{") {
+		return ct
+	}
+
+	t, err := template.New("call_template_data").Funcs(defaultTemplateWorker.funcs).Parse(text)
+	if err != nil {
+		// a text that is not a valid template is used as is
+		return ct
+	}
+
+	for _, node := range t.Tree.Root.Nodes {
+		if node.Type() != parse.NodeText {
+			ct.tmpl = t
+			break
+		}
+	}
+
+	return ct
+}
+
+// isStatic returns whether the text is the same for every call
+func (ct *callTemplate) isStatic() bool {
+	return ct.tmpl == nil
+}
+
+// execute returns the text evaluated with the template data,
+// or the text as is if it is static
+func (ct *callTemplate) execute(td *callTemplateData) ([]byte, error) {
+	if ct.tmpl == nil {
+		return []byte(ct.text), nil
+	}
+
+	var tpl bytes.Buffer
+	if err := ct.template(td.worker).Execute(&tpl, td); err != nil {
+		return nil, err
+	}
+
+	return tpl.Bytes(), nil
+}
+
+// template returns the template with the functions of the worker
+func (ct *callTemplate) template(w *templateWorker) *template.Template {
+	if w == nil || w == defaultTemplateWorker {
+		return ct.tmpl
+	}
+
+	if t, ok := ct.workers.Load(w); ok {
+		return t.(*template.Template)
+	}
+
+	t := template.Must(ct.tmpl.Clone()).Funcs(w.funcs)
+	ct.workers.Store(w, t)
+	return t
+}
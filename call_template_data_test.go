@@ -54,8 +54,13 @@ func TestCallTemplateData_ExecuteData(t *testing.T) {
 		},
 		{"with unknown action",
 			`{"name":"asdf {{.Something}} {{.MethodName}} bob"}`,
-			map[string]interface{}{"name": "asdf {{.Something}} {{.MethodName}} bob"},
-			false,
+			nil,
+			true,
+		},
+		{"with failing func",
+			`{"name":"{{ base64Decode "!!" }}"}`,
+			nil,
+			true,
 		},
 	}
 
@@ -100,8 +105,8 @@ func TestCallTemplateData_ExecuteMetadata(t *testing.T) {
 		},
 		{"with unknown action",
 			`{"trace_id":"asdf {{.Something}} {{.MethodName}} bob"}`,
-			&map[string]string{"trace_id": "asdf {{.Something}} {{.MethodName}} bob"},
-			false,
+			(*map[string]string)(nil),
+			true,
 		},
 	}
 
@@ -118,3 +123,113 @@ func TestCallTemplateData_ExecuteMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestCallTemplateData_ExecuteDataWithSteps(t *testing.T) {
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter/SayHello", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+	assert.NotNil(t, md)
+
+	ctd := newCallTemplateData(md, 300)
+	ctd.Steps = map[string]map[string]interface{}{
+		"login": {
+			"request":  map[string]interface{}{"name": "bob"},
+			"response": map[string]interface{}{"token": "abc123"},
+		},
+	}
+
+	r, err := ctd.executeData(`{"name":"{{.Steps.login.request.name}} {{.Steps.login.response.token}}"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "bob abc123"}, r)
+
+	mdMap, err := ctd.executeMetadata(`{"token":"{{.Steps.login.response.token}}"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, &map[string]string{"token": "abc123"}, mdMap)
+}
+
+func TestCallTemplate_parse(t *testing.T) {
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter/SayHello", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+	assert.NotNil(t, md)
+
+	ctd := newCallTemplateData(md, 400)
+
+	var tests = []struct {
+		name     string
+		in       string
+		static   bool
+		expected string
+		err      bool
+	}{
+		{"no template", `{"name":"bob"}`, true, `{"name":"bob"}`, false},
+		{"with template", `{"name":"{{.RequestNumber}}"}`, false, `{"name":"400"}`, false},
+		{"comment only", `{"name":"bob"{{/* no actions */}}}`, true, `{"name":"bob"{{/* no actions */}}}`, false},
+		{"invalid template", `{"name":"{{.RequestNumber"}`, true, `{"name":"{{.RequestNumber"}`, false},
+		{"with unknown action", `{"name":"{{.Something}}"}`, false, "", true},
+		{"with failing func", `{"name":"{{ base64Decode "!!" }}"}`, false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := parseCallTemplate(tt.in)
+			assert.Equal(t, tt.static, ct.isStatic())
+
+			out, err := ct.execute(ctd)
+			if tt.err {
+				assert.Error(t, err)
+				assert.Nil(t, out)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(out))
+		})
+	}
+}
+
+func BenchmarkCallTemplateData_executeData(b *testing.B) {
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter/SayHello", "./testdata/greeter.proto", []string{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	data := `{"name":"{{.RequestNumber}} {{.MethodName}}"}`
+
+	b.Run("parse every call", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ctd := newCallTemplateData(md, int64(i))
+			if _, err := ctd.executeData(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("parsed once", func(b *testing.B) {
+		b.ReportAllocs()
+		ct := parseCallTemplate(data)
+		for i := 0; i < b.N; i++ {
+			ctd := newCallTemplateData(md, int64(i))
+			input, err := ct.execute(ctd)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := unmarshalData(input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestCallTemplateData_ExecuteDataWithWorker(t *testing.T) {
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter/SayHello", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+	assert.NotNil(t, md)
+
+	ctd := newCallTemplateData(md, 500)
+	ctd.WorkerID = 3
+	ctd.WorkerRequestNumber = 7
+	ctd.worker = newTemplateWorker(3, 1)
+
+	r, err := ctd.executeData(`{"name":"{{.WorkerID}}-{{.WorkerRequestNumber}} {{randomInt 5 5}}"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "3-7 5"}, r)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/tab1293/ghz/config"
+	"github.com/tab1293/ghz/protodesc"
+)
+
+// isCommand returns whether the name is one of the commands run instead of a test
+func isCommand(name string) bool {
+	return name == "list" || name == "describe"
+}
+
+// runCommand runs the list or describe command with the arguments following it
+func runCommand(name string, args []string) {
+	flag.CommandLine.Parse(args)
+
+	cfg, err := commandConfig()
+	if err != nil {
+		usageAndExit(err.Error())
+	}
+
+	switch name {
+	case "list":
+		err = runList(cfg, os.Stdout)
+	case "describe":
+		err = runDescribe(cfg, os.Stdout)
+	}
+
+	if err != nil {
+		errAndExit(err.Error())
+	}
+}
+
+// commandConfig creates the config of the command from the flags,
+// with the host as the optional argument
+func commandConfig() (*config.Config, error) {
+	cfg := &config.Config{
+		Proto:         *proto,
+		Protoset:      *protoset,
+		Call:          *call,
+		Cert:          *cert,
+		CName:         *cname,
+		Insecure:      *insecure,
+		Timeout:       *t,
+		DialTimeout:   *ct,
+		KeepaliveTime: *kt,
+		ImportPaths:   importPaths(),
+	}
+
+	if flag.NArg() > 0 {
+		cfg.Host = flag.Arg(0)
+	}
+
+	if strings.TrimSpace(cfg.Proto) == "" && strings.TrimSpace(cfg.Protoset) == "" && cfg.Host == "" {
+		return nil, errors.New("proto, protoset or host with server reflection is required")
+	}
+
+	if strings.TrimSpace(*md) != "" {
+		if err := json.Unmarshal([]byte(*md), &cfg.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.Default()
+
+	return cfg, nil
+}
+
+// runList prints every service followed by its methods
+func runList(cfg *config.Config, w io.Writer) error {
+	services, err := getServices(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, sd := range services {
+		fmt.Fprintln(w, sd.GetFullyQualifiedName())
+		for _, mtd := range sd.GetMethods() {
+			fmt.Fprintf(w, "  %s\n", mtd.GetFullyQualifiedName())
+		}
+	}
+
+	return nil
+}
+
+// runDescribe prints the streaming kind and the input and output types of the call,
+// with a JSON skeleton of each
+func runDescribe(cfg *config.Config, w io.Writer) error {
+	if strings.TrimSpace(cfg.Call) == "" {
+		return errors.New("call is required")
+	}
+
+	mtd, err := getMethodDesc(cfg, cfg.Call)
+	if err != nil {
+		return err
+	}
+
+	request, err := protodesc.MessageSkeleton(mtd.GetInputType())
+	if err != nil {
+		return err
+	}
+
+	response, err := protodesc.MessageSkeleton(mtd.GetOutputType())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, mtd.GetFullyQualifiedName())
+	fmt.Fprintf(w, "  Streaming:  %s\n", streamingKind(mtd))
+	fmt.Fprintf(w, "  Input:      %s\n", mtd.GetInputType().GetFullyQualifiedName())
+	fmt.Fprintf(w, "  Output:     %s\n", mtd.GetOutputType().GetFullyQualifiedName())
+	fmt.Fprintf(w, "\nRequest:\n%s\n", request)
+	fmt.Fprintf(w, "\nResponse:\n%s\n", response)
+
+	return nil
+}
+
+func streamingKind(mtd *desc.MethodDescriptor) string {
+	switch {
+	case mtd.IsClientStreaming() && mtd.IsServerStreaming():
+		return "bidi streaming"
+	case mtd.IsClientStreaming():
+		return "client streaming"
+	case mtd.IsServerStreaming():
+		return "server streaming"
+	}
+	return "unary"
+}
+
+// getServices returns the services of the proto or protoset files,
+// or of the host using server reflection
+func getServices(cfg *config.Config) ([]*desc.ServiceDescriptor, error) {
+	if cfg.Proto != "" {
+		files, err := protodesc.ParseProtos(cfg.ProtoFiles(), cfg.ImportPaths)
+		if err != nil {
+			return nil, err
+		}
+		return protodesc.GetServices(files), nil
+	}
+
+	if cfg.Protoset != "" {
+		files, err := protodesc.ParseProtoSet(cfg.Protoset)
+		if err != nil {
+			return nil, err
+		}
+		return protodesc.GetServices(files), nil
+	}
+
+	reflector, err := newReflector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer reflector.Close()
+
+	return reflector.Services()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz/config"
+)
+
+func TestCommands_list(t *testing.T) {
+	t.Run("proto", func(t *testing.T) {
+		var out bytes.Buffer
+		err := runList(&config.Config{Proto: "../../testdata/greeter.proto"}, &out)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `helloworld.Greeter
+  helloworld.Greeter.SayHello
+  helloworld.Greeter.SayHelloCS
+  helloworld.Greeter.SayHellos
+  helloworld.Greeter.SayHelloBidi
+`, out.String())
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		var out bytes.Buffer
+		err := runList(&config.Config{Proto: "../../testdata/multi"}, &out)
+
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), "multi.Api\n")
+		assert.Contains(t, out.String(), "multi.Echo\n  multi.Echo.Echo\n")
+	})
+
+	t.Run("protoset", func(t *testing.T) {
+		var out bytes.Buffer
+		err := runList(&config.Config{Protoset: "../../testdata/bundle.protoset"}, &out)
+
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), "helloworld.Greeter\n  helloworld.Greeter.SayHello\n")
+	})
+
+	t.Run("invalid proto", func(t *testing.T) {
+		var out bytes.Buffer
+		err := runList(&config.Config{Proto: "../../testdata/invalid.proto"}, &out)
+
+		assert.Error(t, err)
+		assert.Empty(t, out.String())
+	})
+}
+
+func TestCommands_describe(t *testing.T) {
+	t.Run("unary", func(t *testing.T) {
+		var out bytes.Buffer
+		err := runDescribe(&config.Config{Proto: "../../testdata/greeter.proto", Call: "helloworld.Greeter.SayHello"}, &out)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `helloworld.Greeter.SayHello
+  Streaming:  unary
+  Input:      helloworld.HelloRequest
+  Output:     helloworld.HelloReply
+
+Request:
+{
+  "name": ""
+}
+
+Response:
+{
+  "message": ""
+}
+`, out.String())
+	})
+
+	var tests = []struct {
+		call     string
+		expected string
+	}{
+		{"helloworld.Greeter.SayHelloCS", "client streaming"},
+		{"helloworld.Greeter/SayHellos", "server streaming"},
+		{"helloworld.Greeter.SayHelloBidi", "bidi streaming"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runDescribe(&config.Config{Proto: "../../testdata/greeter.proto", Call: tt.call}, &out)
+
+			assert.NoError(t, err)
+			assert.Contains(t, out.String(), "  Streaming:  "+tt.expected+"\n")
+		})
+	}
+
+	t.Run("unknown service", func(t *testing.T) {
+		var out bytes.Buffer
+		err := runDescribe(&config.Config{Proto: "../../testdata/greeter.proto", Call: "helloworld.Unknown.SayHello"}, &out)
+
+		assert.EqualError(t, err, `cannot find service "helloworld.Unknown", available services: helloworld.Greeter`)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		var out bytes.Buffer
+		err := runDescribe(&config.Config{Proto: "../../testdata/greeter.proto", Call: "helloworld.Greeter.Unknown"}, &out)
+
+		assert.EqualError(t, err, `service "helloworld.Greeter" does not include a method named "Unknown", available methods: SayHello, SayHelloCS, SayHellos, SayHelloBidi`)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("no call", func(t *testing.T) {
+		var out bytes.Buffer
+		err := runDescribe(&config.Config{Proto: "../../testdata/greeter.proto"}, &out)
+
+		assert.EqualError(t, err, "call is required")
+	})
+}
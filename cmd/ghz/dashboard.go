@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tab1293/ghz"
+)
+
+// The width of the progress bar in characters
+const barWidth = 30
+
+// The levels of the sparkline of the latency, from the fastest to the slowest
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// dashboard shows the progress of a run, redrawn in place every second
+// on a terminal and as a line per second otherwise
+type dashboard struct {
+	out io.Writer
+	tty bool
+
+	// the number of lines drawn last on the terminal
+	lines int
+}
+
+func newDashboard(f *os.File) *dashboard {
+	d := &dashboard{out: f}
+	if fi, err := f.Stat(); err == nil {
+		d.tty = fi.Mode()&os.ModeCharDevice != 0
+	}
+	return d
+}
+
+// update shows the progress of the run
+func (d *dashboard) update(p ghz.Progress) {
+	if !d.tty {
+		fmt.Fprintln(d.out, progressLine(&p))
+		return
+	}
+
+	var buf bytes.Buffer
+	d.rewind(&buf)
+	lines := dashboardLines(&p)
+	for _, l := range lines {
+		buf.WriteString("\x1b[2K")
+		buf.WriteString(l)
+		buf.WriteByte('\n')
+	}
+	d.out.Write(buf.Bytes())
+	d.lines = len(lines)
+}
+
+// clear erases the dashboard from the terminal so that the report follows
+// the output preceding the run
+func (d *dashboard) clear() {
+	if d.lines == 0 {
+		return
+	}
+
+	var buf bytes.Buffer
+	d.rewind(&buf)
+	d.out.Write(buf.Bytes())
+	d.lines = 0
+}
+
+// rewind moves the cursor to the first line of the dashboard and erases it all
+func (d *dashboard) rewind(buf *bytes.Buffer) {
+	if d.lines > 0 {
+		fmt.Fprintf(buf, "\x1b[%dA", d.lines)
+	}
+	buf.WriteString("\x1b[J")
+}
+
+func dashboardLines(p *ghz.Progress) []string {
+	elapsed := p.Elapsed.Round(time.Second).String()
+	if p.Duration > 0 {
+		elapsed += " / " + p.Duration.String()
+	}
+
+	calls := fmt.Sprintf("%d", p.Count)
+	if p.Duration == 0 && p.N > 0 {
+		calls += fmt.Sprintf("/%d", p.N)
+	}
+
+	window := p.Window.Round(time.Second).String()
+
+	res := []string{
+		"Elapsed:   " + elapsed,
+		fmt.Sprintf("Progress:  %s %3.0f%%  %s calls", bar(p.Done()), p.Done()*100, calls),
+		fmt.Sprintf("Rps:       %.1f in the last %s (average %.1f)", p.Rps, window, p.AverageRps),
+		fmt.Sprintf("Latency:   %s in the last %s", formatLatencies(p.LatencyDistribution, "  "), window),
+		"           " + sparkline(p.History),
+	}
+
+	if len(p.Errors) > 0 {
+		res = append(res, "Errors:    "+formatErrors(p.Errors, ", ", " "))
+	}
+
+	return res
+}
+
+// progressLine returns the progress on a single line
+// such as [12s] 4500/10000 45% | last 10s: 1012.3 rps p50 1.20 ms ... | avg 998.7 rps
+func progressLine(p *ghz.Progress) string {
+	calls := fmt.Sprintf("%d", p.Count)
+	if p.Duration == 0 && p.N > 0 {
+		calls += fmt.Sprintf("/%d", p.N)
+	}
+
+	res := fmt.Sprintf("[%s] %s", p.Elapsed.Round(time.Second), calls)
+	if p.Duration > 0 || p.N > 0 {
+		res += fmt.Sprintf(" %.0f%%", p.Done()*100)
+	}
+
+	res += fmt.Sprintf(" | last %s: %.1f rps %s | avg %.1f rps", p.Window.Round(time.Second),
+		p.Rps, formatLatencies(p.LatencyDistribution, " "), p.AverageRps)
+
+	if len(p.Errors) > 0 {
+		res += " | errors: " + formatErrors(p.Errors, " ", ":")
+	}
+
+	return res
+}
+
+func bar(done float64) string {
+	n := int(done * barWidth)
+	return "[" + strings.Repeat("█", n) + strings.Repeat("░", barWidth-n) + "]"
+}
+
+func formatLatencies(lats []ghz.LatencyDistribution, sep string) string {
+	var res []string
+	for _, l := range lats {
+		res = append(res, fmt.Sprintf("p%v %.2f ms", l.Percentage, l.Latency.Seconds()*1000))
+	}
+	return strings.Join(res, sep)
+}
+
+// formatErrors returns the number of errors by status code sorted by the code
+func formatErrors(errs map[string]int, sep, kv string) string {
+	codes := make([]string, 0, len(errs))
+	for code := range errs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	res := make([]string, len(codes))
+	for i, code := range codes {
+		res[i] = fmt.Sprintf("%s%s%d", code, kv, errs[code])
+	}
+	return strings.Join(res, sep)
+}
+
+// sparkline returns the latency history scaled from the fastest to the
+// slowest, leaving blank the seconds no call ended in
+func sparkline(history []time.Duration) string {
+	var min, max time.Duration
+	for _, lat := range history {
+		if lat > 0 && (min == 0 || lat < min) {
+			min = lat
+		}
+		if lat > max {
+			max = lat
+		}
+	}
+
+	res := make([]rune, len(history))
+	for i, lat := range history {
+		switch {
+		case lat == 0:
+			res[i] = ' '
+		case max == min:
+			res[i] = sparks[0]
+		default:
+			res[i] = sparks[int(float64(lat-min)/float64(max-min)*float64(len(sparks)-1))]
+		}
+	}
+	return string(res)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"runtime"
@@ -35,15 +36,58 @@ var (
 	z = flag.Duration("z", 0, "Duration of application to send requests.")
 	x = flag.Duration("x", 0, "Maximum duration of application to send requests.")
 
+	openLoop    = flag.Bool("openloop", false, "Issue requests at the aggregate -q rate regardless of in-flight latency.")
+	maxInFlight = flag.Int("inflight", 0, "Maximum number of in-flight requests in open loop mode.")
+	stages      = flag.String("stages", "", "Comma separated list of load stages.")
+
+	search     = flag.String("search", "", "Range of load to search for the maximum load within the SLO.")
+	searchBy   = flag.String("searchby", "qps", "Load stepped by the search, qps or c.")
+	searchStep = flag.Duration("searchstep", 10*time.Second, "Duration of each step of the search.")
+	slo        = flag.String("slo", "", "Comma separated list of service level objectives for the search.")
+
 	data     = flag.String("d", "", "The call data as stringified JSON. If the value is '@' then the request contents are read from stdin.")
 	dataPath = flag.String("D", "", "Path for call data JSON file.")
+	binPath  = flag.String("B", "", "Path for the call data as serialized protobuf.")
+	dataFmt  = flag.String("dataformat", "", "Format of the call data file, json or jsonl.")
+	dataMode = flag.String("datamode", "", "How the lines of a jsonl data file are used, roundrobin or once.")
+	csvPath  = flag.String("csv", "", "Path of a CSV file with a row of template variables for every call.")
+	csvMode  = flag.String("csvmode", "", "How the rows of the CSV file are picked, sequential, random or partition.")
+	csvEnd   = flag.String("csvend", "", "What happens at the end of the CSV rows, wrap or stop.")
+	seed     = flag.Int64("seed", 0, "Seed of the random template functions and random data.")
 	md       = flag.String("m", "", "Request metadata as stringified JSON.")
 	mdPath   = flag.String("M", "", "Path for call metadata JSON file.")
 
+	random         = flag.Bool("random", false, "Send random messages generated from the input type.")
+	randomLength   = flag.Int("randomlength", 0, "Length of the string and bytes fields of random messages.")
+	randomRepeated = flag.Int("randomrepeated", 0, "Number of elements of the repeated and map fields of random messages.")
+	randomDepth    = flag.Int("randomdepth", 0, "Maximum depth of the nested messages of random messages.")
+
+	record       = flag.String("record", "", "Path of the file the calls are recorded to.")
+	recordFormat = flag.String("recordformat", "", "Format of the record file, jsonl or bin.")
+	recordEvery  = flag.Int("recordevery", 0, "Record every Nth call.")
+	recordErrors = flag.Bool("recorderrors", false, "Record only the calls that fail.")
+	recordMax    = flag.Int("recordmax", 0, "Maximum number of calls recorded.")
+
 	paths = flag.String("i", "", "Comma separated list of proto import paths")
 
 	output = flag.String("o", "", "Output path")
 	format = flag.String("O", "", "Output format")
+	table  = flag.String("table", "", "Table of the csv output, details, percentiles, histogram, status or intervals.")
+
+	details     = flag.Int("details", 0, "Maximum number of calls listed in the report.")
+	precision   = flag.Int("precision", 0, "Significant figures of the latency percentiles.")
+	percentiles = flag.String("percentiles", "", "Comma separated list of the latency percentiles reported.")
+	buckets     = flag.String("buckets", "", "Layout of the buckets of the latency histogram.")
+	interval    = flag.Duration("interval", 0, "Length of the intervals of the timeline of the results.")
+
+	progress      = flag.Bool("progress", false, "Show the progress of the run on stderr.")
+	metrics       = flag.String("metrics", "", "Address the live metrics are served on in the Prometheus format.")
+	metricsLinger = flag.Duration("metricslinger", 0, "How long the metrics are still served once the calls are over.")
+
+	measurement = flag.String("measurement", "", "Name of the measurement of the influx output.")
+	tags        = flag.String("tags", "", "Comma separated list of the key=value tags of the influx output.")
+
+	thresholds = flag.String("thresholds", "", "Comma separated list of the thresholds the report is checked against.")
 
 	ct = flag.Int("T", 10, "Connection timeout in seconds for the initial connection dial.")
 	kt = flag.Int("L", 0, "Keepalive time in seconds.")
@@ -56,9 +100,23 @@ var (
 )
 
 var usage = `Usage: ghz [options...] <host>
+       ghz list [options...] [<host>]
+       ghz describe [options...] -call <method> [<host>]
+
+Commands:
+  list      List the services and methods of the -proto or -protoset files, or of the host
+            using server reflection.
+  describe  Describe the -call method, its streaming kind and its input and output messages,
+            along with a JSON skeleton of the messages with every field set to a default.
+            The request skeleton can be used as a starting point for -d.
+  The proto, call, connection and -m options apply to the commands.
+
 Options:
-  -proto	The protocol buffer file.
+  -proto	The protocol buffer file. A comma separated list of files, glob patterns and
+		directories can be given, a directory standing for all the proto files in it.
   -protoset	The compiled protoset file. Alternative to proto. -proto takes precedence.
+		If neither -proto nor -protoset is given, the method is resolved with the
+		server reflection service of the host.
   -call		A fully-qualified method name in 'service/method' or 'service.method' format.
   -cert		The file containing the CA root cert file.
   -cname	An override of the expect Server Cname presented by the server.
@@ -69,6 +127,7 @@ Options:
       be smaller than the concurrency level. Default is 50.
   -n  Number of requests to run. Default is 200.
   -q  Rate limit, in queries per second (QPS). Default is no rate limit.
+      Without -openloop the limit is applied by each of the -c workers.
   -t  Timeout for each request in seconds. Default is 20, use 0 for infinite.
   -z  Duration of application to send requests. When duration is reached,
       application stops and exits. If duration is specified, n is ignored.
@@ -77,18 +136,117 @@ Options:
       If duration is reached before n requests are completed, application stops and exits.
       Examples: -x 10s -x 3m.
 
+  -openloop  Open-loop mode. A single scheduler issues requests at the aggregate -q rate
+             regardless of how long in-flight requests take. Requires -q.
+  -inflight  Maximum number of in-flight requests in open-loop mode. When the cap is hit
+             the request is dropped and counted. Default is the -c value.
+  -stages    Comma separated list of load stages run one after another, each in the
+             <duration>:<qps>[:<concurrency>] format. QPS and concurrency can be a single
+             value or a <from>-<to> range that is ramped linearly over the stage. A stage
+             with a QPS is driven by an open-loop scheduler with the concurrency as the
+             in-flight cap, otherwise by the given number of workers. For example:
+             -stages 30s:10-500,5m:500,30s:500-10 or -stages 1m:0:10-100.
+             If stages are specified, n is ignored.
+
+  -search      Search for the highest load that meets the SLO within the <min>-<max> range.
+               Each step of the search runs for -searchstep and is checked against -slo.
+               For example: -search 10-1000.
+  -searchby    The load stepped by the search. "qps" steps the open-loop rate without any
+               in-flight cap, "c" steps the concurrency. Default is qps.
+  -searchstep  Duration of each step of the search. Default is 10s.
+  -slo         Comma separated list of service level objectives the search checks every step
+               against. Latency percentiles, average, fastest and slowest, the errors rate and
+               rps can be used. For example: -slo 'p99<50ms,errors<0.1%%'.
+
   -d  The call data as stringified JSON.
       If the value is '@' then the request contents are read from stdin.
   -D  Path for call data JSON file. For example, /home/user/file.json or ./file.json.
+      A JSON Lines file, with one message or array of messages per line, is streamed
+      handing the next line to every call. Files with the .jsonl extension are read as
+      JSON Lines unless -dataformat is set.
+  -dataformat  The format of the call data file, json or jsonl.
+  -datamode    How the lines of a JSON Lines data file are used. "roundrobin" starts over at
+               the end of the file, "once" uses every line once and then stops the run.
+               Default is roundrobin.
+  -B  Path for the call data as serialized protobuf, used instead of -d and -D. For client
+      streaming it is a stream of messages each prefixed with its size as a varint, as written
+      by writeDelimitedTo in the Java and parseDelimitedFrom in the C++ protobuf libraries.
+  -csv      Path of a CSV file with a header row. Every call gets a row, available to the
+            data and metadata templates as .Row by column name. For example: {{.Row.user_id}}.
+  -csvmode  How the rows are picked. "sequential" uses them in order, "random" at random and
+            "partition" gives each of the -c workers its own share of the rows, or each
+            of the workers of the stages. It cannot be used in open-loop mode or with
+            rate limited stages. Default is sequential.
+  -csvend   What happens at the end of the rows in sequential and partition mode. "wrap"
+            starts over and "stop" stops the run. Default is wrap.
+  -seed     Seed of the random values of the template functions and of -random, so that
+            runs are reproducible. Each worker gets its own sequence of values. By default a
+            different seed is used every run.
+  -random   Send random messages generated from the schema of the input type instead of -d.
+            Every field is set, with one field of each oneof. For client streaming the stream
+            has -randomrepeated messages.
+  -randomlength    Length of the string and bytes fields of random messages. Default is 16.
+  -randomrepeated  Number of elements of the repeated and map fields of random messages.
+                   Default is 3.
+  -randomdepth     Maximum depth of the nested messages of random messages. Default is 3.
   -m  Request metadata as stringified JSON.
   -M  Path for call metadata JSON file. For example, /home/user/metadata.json or ./metadata.json.
 
+  -record        Path of a file the calls are recorded to, with the request, metadata, response,
+                 status, trailers and latency of each call. Every message received is recorded
+                 for server streaming.
+  -recordformat  Format of the record file. "jsonl" writes a JSON object per line and "bin" a
+                 Record protobuf message per call, each prefixed with its size as a varint.
+                 Default is jsonl.
+  -recordevery   Record only every Nth call, or every Nth failed call with -recorderrors.
+  -recorderrors  Record only the calls that fail, including the ones failing an assertion.
+  -recordmax     Maximum number of calls recorded. Default is no limit.
+
   -o  Output path. If none provided stdout is used.
   -O  Output type. If none provided, a summary is printed.
-      "csv" outputs the response metrics in comma-separated values format.
+      "csv" outputs the response metrics in comma-separated values format, the table of -table.
       "json" outputs the metrics report in JSON format.
       "pretty" outputs the metrics report in pretty JSON format.
       "html" outputs the metrics report as HTML.
+      "influx" outputs the metrics report as InfluxDB line protocol.
+      "junit" outputs the outcome of the -thresholds as JUnit XML, a test case each, or
+      of the run as a single test case failing if any call failed without thresholds.
+  -table  The table of the csv output. "details" lists the calls, "percentiles" the latency
+          distribution, "histogram" the latency buckets, "status" the latency by status code
+          and "intervals" the timeline of the run. Default is details.
+  -details      Maximum number of calls listed in the details of the report, with the time,
+                latency, status and error of each. Default is 100000 for the csv details and html, and none otherwise.
+  -precision    Significant figures of the latency percentiles, from 1 to 5. The memory used
+                does not grow with the number of calls. Default is 3, within 0.1%% of the latency.
+  -percentiles  Comma separated list of the latency percentiles reported, such as 50,99,99.9.
+                Default is 10,25,50,75,90,95,99.
+  -buckets      Layout of the buckets of the latency histogram. "linear" spreads them evenly
+                from the fastest to the slowest call, and "exponential" grows them by the same
+                factor, each optionally followed by the number of buckets as in exponential:20.
+                A comma separated list of the bucket marks in ms such as 1,5,10,50 can be given
+                instead. Default is linear:10.
+  -interval     Length of the intervals of the timeline of the results in the report, with the
+                rps, errors by status code and latency of the calls ending in each. Default is 1s.
+  -measurement  Name of the measurement of the summary in the influx output, and the prefix of
+                the other measurements. Default is ghz.
+  -tags         Comma separated list of the tags of every point of the influx output, such as
+                test=checkout,sha=1a2b3c,env=staging.
+  -thresholds   Comma separated list of thresholds the report is checked against once the run is
+                over, with the same metrics as -slo. The outcome of each is reported, and ghz
+                exits with status 1 if any fails. For example: -thresholds 'p95<100ms,errors<1%%,rps>500'.
+
+  -progress  Show the progress of the run on stderr every second: the elapsed time, the calls
+             made toward -n or -z, the rps and the p50, p95 and p99 latency of the last 10
+             seconds, the average rps, the errors by status code and the median latency of
+             every second of the last minute. A dashboard
+             is redrawn in place on a terminal, and a line is printed otherwise.
+  -metrics   Address such as :9090 the live metrics of the run are served on at /metrics in the
+             Prometheus text format while it runs: the calls and errors by method and status
+             code, their latency histogram, the calls in flight, the target and average rps,
+             and the state of the connection and its changes.
+  -metricslinger  How long the final metrics are still served once the calls are over, so
+             that they are scraped, such as 30s. The report is printed after it, and a second
+             interrupt exits during it. Default is 0.
 
   -i  Comma separated list of proto import paths. The current working directory and the directory
 	  of the protocol buffer file are automatically added to the import list.
@@ -106,6 +264,11 @@ func main() {
 		fmt.Fprint(os.Stderr, fmt.Sprintf(usage, runtime.NumCPU()))
 	}
 
+	if len(os.Args) > 1 && isCommand(os.Args[1]) {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	flag.Parse()
 
 	if *v {
@@ -135,14 +298,61 @@ func main() {
 
 		host := flag.Args()[0]
 
-		iPaths := []string{}
-		pathsTrimmed := strings.TrimSpace(*paths)
-		if pathsTrimmed != "" {
-			iPaths = strings.Split(pathsTrimmed, ",")
-		}
-
-		cfg, err = config.New(*proto, *protoset, *call, *cert, *cname, *n, *c, *q, *z, *x, *t,
-			*data, *dataPath, *md, *mdPath, *output, *format, host, *ct, *kt, *cpus, iPaths, *insecure)
+		cfg, err = config.NewWithFlags(&config.Config{
+			Proto:         *proto,
+			Protoset:      *protoset,
+			Call:          *call,
+			Cert:          *cert,
+			CName:         *cname,
+			N:             *n,
+			C:             *c,
+			QPS:           *q,
+			Z:             *z,
+			X:             *x,
+			Timeout:       *t,
+			DataPath:      *dataPath,
+			BinaryPath:    *binPath,
+			DataFormat:    *dataFmt,
+			DataMode:      *dataMode,
+			CSV:           *csvPath,
+			CSVMode:       *csvMode,
+			CSVEnd:        *csvEnd,
+			Seed:          *seed,
+			Random:        *random,
+			RandomLength:  *randomLength,
+			RandomRepeat:  *randomRepeated,
+			RandomDepth:   *randomDepth,
+			Record:        *record,
+			RecordFormat:  *recordFormat,
+			RecordEvery:   *recordEvery,
+			RecordErrors:  *recordErrors,
+			RecordMax:     *recordMax,
+			MetadataPath:  *mdPath,
+			Output:        *output,
+			Format:        *format,
+			Table:         *table,
+			Details:       *details,
+			Precision:     *precision,
+			Buckets:       *buckets,
+			Interval:      *interval,
+			Progress:      *progress,
+			Metrics:       *metrics,
+			MetricsLinger: *metricsLinger,
+			Measurement:   *measurement,
+			Thresholds:    *thresholds,
+			Host:          host,
+			ImportPaths:   importPaths(),
+			DialTimeout:   *ct,
+			KeepaliveTime: *kt,
+			CPUs:          *cpus,
+			Insecure:      *insecure,
+			OpenLoop:      *openLoop,
+			MaxInFlight:   *maxInFlight,
+			Search:        *search,
+			SearchBy:      *searchBy,
+			SearchStep:    *searchStep,
+			SLO:           *slo,
+		}, config.Flags{Data: *data, Metadata: *md, Stages: *stages, Tags: *tags, Percentiles: *percentiles})
 		if err != nil {
 			errAndExit(err.Error())
 		}
@@ -150,27 +360,75 @@ func main() {
 
 	runtime.GOMAXPROCS(cfg.CPUs)
 
+	if strings.TrimSpace(cfg.Search) != "" {
+		report, err := runSearch(cfg)
+		if err != nil {
+			errAndExit(err.Error())
+		}
+
+		output := createOutput(cfg)
+		defer output.Close()
+
+		p := printer.SearchPrinter{
+			Report: report,
+			Out:    output}
+
+		p.Print(cfg.Format)
+		return
+	}
+
 	report, err := runTest(cfg)
 	if err != nil {
 		errAndExit(err.Error())
 	}
 
-	output := os.Stdout
-	outputPath := strings.TrimSpace(cfg.Output)
-	if outputPath != "" {
-		f, err := os.Create(outputPath)
-		if err != nil {
-			errAndExit(err.Error())
-		}
-		defer f.Close()
-		output = f
-	}
+	output := createOutput(cfg)
+	defer output.Close()
 
 	p := printer.ReportPrinter{
 		Report: report,
-		Out:    output}
+		Out:    output,
+		Table:  cfg.Table,
+		Influx: printer.InfluxOptions{Measurement: cfg.Measurement, Tags: cfg.Tags}}
 
 	p.Print(cfg.Format)
+
+	if failed := report.FailedThresholds(); len(failed) > 0 {
+		exprs := make([]string, len(failed))
+		for i, t := range failed {
+			exprs[i] = t.Threshold.Expr
+		}
+
+		// the deferred close is skipped on exit
+		output.Close()
+		fmt.Fprintln(os.Stderr, "Thresholds failed:", strings.Join(exprs, ", "))
+		os.Exit(1)
+	}
+}
+
+// importPaths returns the comma separated list of proto import paths
+func importPaths() []string {
+	iPaths := []string{}
+	pathsTrimmed := strings.TrimSpace(*paths)
+	if pathsTrimmed != "" {
+		iPaths = strings.Split(pathsTrimmed, ",")
+	}
+	return iPaths
+}
+
+// createOutput creates the output file, or returns stdout if no output path is set
+func createOutput(cfg *config.Config) *os.File {
+	outputPath := strings.TrimSpace(cfg.Output)
+	if outputPath == "" {
+		return os.Stdout
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		errAndExit(err.Error())
+	}
+
+	return f
 }
 
 func errAndExit(msg string) {
@@ -190,25 +448,20 @@ func usageAndExit(msg string) {
 }
 
 func runTest(config *config.Config) (*ghz.Report, error) {
-	mtd, err := getMethodDesc(config)
+	mtd, err := getMainMethodDesc(config)
 	if err != nil {
 		return nil, err
 	}
 
-	opts := &ghz.Options{
-		Host:          config.Host,
-		Cert:          config.Cert,
-		CName:         config.CName,
-		N:             config.N,
-		C:             config.C,
-		QPS:           config.QPS,
-		Z:             config.Z,
-		Timeout:       config.Timeout,
-		DialTimtout:   config.DialTimeout,
-		KeepaliveTime: config.KeepaliveTime,
-		Data:          config.Data,
-		Metadata:      config.Metadata,
-		Insecure:      config.Insecure,
+	opts, err := createOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Progress {
+		d := newDashboard(os.Stderr)
+		opts.OnProgress = d.update
+		defer d.clear()
 	}
 
 	reqr, err := ghz.New(mtd, opts)
@@ -221,6 +474,8 @@ func runTest(config *config.Config) (*ghz.Report, error) {
 	go func() {
 		<-cancel
 		reqr.Stop()
+		// a second interrupt exits, such as while the metrics linger
+		signal.Stop(cancel)
 	}()
 
 	if config.Z > 0 {
@@ -234,10 +489,223 @@ func runTest(config *config.Config) (*ghz.Report, error) {
 	return reqr.Run()
 }
 
-func getMethodDesc(config *config.Config) (*desc.MethodDescriptor, error) {
+func runSearch(config *config.Config) (*ghz.SearchReport, error) {
+	mtd, err := getMainMethodDesc(config)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := createOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	slo, err := ghz.ParseThresholds(config.SLO)
+	if err != nil {
+		return nil, err
+	}
+
+	min, max, err := config.SearchRange()
+	if err != nil {
+		return nil, err
+	}
+
+	searcher, err := ghz.NewSearcher(mtd, opts, &ghz.SearchOptions{
+		By:           config.SearchBy,
+		Min:          min,
+		Max:          max,
+		StepDuration: config.SearchStep,
+		SLO:          slo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cancel := make(chan os.Signal, 1)
+	signal.Notify(cancel, os.Interrupt)
+	go func() {
+		<-cancel
+		searcher.Stop()
+	}()
+
+	return searcher.Run()
+}
+
+func createOptions(config *config.Config) (*ghz.Options, error) {
+	opts := &ghz.Options{
+		Host:          config.Host,
+		Cert:          config.Cert,
+		CName:         config.CName,
+		N:             config.N,
+		C:             config.C,
+		QPS:           config.QPS,
+		Z:             config.Z,
+		Timeout:       config.Timeout,
+		DialTimtout:   config.DialTimeout,
+		KeepaliveTime: config.KeepaliveTime,
+		Data:          config.Data,
+		DataMode:      config.DataMode,
+		CSVPath:       config.CSV,
+		CSVMode:       config.CSVMode,
+		CSVEnd:        config.CSVEnd,
+		Seed:          config.Seed,
+		Metadata:      config.Metadata,
+		Insecure:      config.Insecure,
+		OpenLoop:      config.OpenLoop,
+		MaxInFlight:   config.MaxInFlight,
+		Stages:        config.Stages,
+		Assertions:    createAssertions(config.Assert),
+		Details:       config.Details,
+		Precision:     config.Precision,
+		Percentiles:   config.Percentiles,
+		Interval:      config.Interval,
+		MetricsAddr:   config.Metrics,
+		MetricsLinger: config.MetricsLinger,
+	}
+
+	if config.DataFormat == "jsonl" {
+		opts.DataPath = config.DataPath
+	}
+
+	if config.Random {
+		opts.Random = &ghz.RandomOptions{
+			Length:   config.RandomLength,
+			Repeated: config.RandomRepeat,
+			Depth:    config.RandomDepth,
+		}
+	}
+
+	if strings.TrimSpace(config.Buckets) != "" {
+		b, err := ghz.ParseBuckets(config.Buckets)
+		if err != nil {
+			return nil, err
+		}
+		opts.Buckets = b
+	}
+
+	if strings.TrimSpace(config.Thresholds) != "" {
+		ts, err := ghz.ParseThresholds(config.Thresholds)
+		if err != nil {
+			return nil, err
+		}
+		opts.Thresholds = ts
+	}
+
+	if strings.TrimSpace(config.Record) != "" {
+		opts.Record = &ghz.RecordOptions{
+			Path:       config.Record,
+			Format:     config.RecordFormat,
+			Every:      config.RecordEvery,
+			ErrorsOnly: config.RecordErrors,
+			Max:        config.RecordMax,
+		}
+	}
+
+	if strings.TrimSpace(config.BinaryPath) != "" {
+		b, err := ioutil.ReadFile(config.BinaryPath)
+		if err != nil {
+			return nil, err
+		}
+		opts.BinaryData = b
+	}
+
+	var err error
+	opts.Calls, err = createCalls(config, config.Calls, "calls: call")
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Steps, err = createCalls(config, config.Steps, "steps: step")
+	if err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+func createCalls(config *config.Config, calls []config.Call, label string) ([]ghz.Call, error) {
+	var res []ghz.Call
+	for i, c := range calls {
+		mtd, err := getMethodDesc(config, c.Call)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %v", label, i+1, err)
+		}
+
+		call := ghz.Call{
+			Name:       c.Name,
+			Method:     mtd,
+			Weight:     c.Weight,
+			Data:       c.Data,
+			Metadata:   c.Metadata,
+			Assertions: createAssertions(c.Assert),
+		}
+
+		if strings.TrimSpace(c.BinaryPath) != "" {
+			call.BinaryData, err = ioutil.ReadFile(c.BinaryPath)
+			if err != nil {
+				return nil, fmt.Errorf("%s %d: %v", label, i+1, err)
+			}
+		}
+
+		res = append(res, call)
+	}
+
+	return res, nil
+}
+
+func createAssertions(assertions []config.Assertion) []ghz.Assertion {
+	var res []ghz.Assertion
+	for _, a := range assertions {
+		res = append(res, ghz.Assertion{
+			Name:     a.Name,
+			Status:   a.Status,
+			Path:     a.Path,
+			Equals:   a.Equals,
+			Matches:  a.Matches,
+			Messages: a.Messages,
+		})
+	}
+	return res
+}
+
+// getMainMethodDesc returns the descriptor of the call method,
+// or nil if the calls of a traffic mix or a sequence are used instead
+func getMainMethodDesc(config *config.Config) (*desc.MethodDescriptor, error) {
+	if len(config.Calls) > 0 || len(config.Steps) > 0 {
+		return nil, nil
+	}
+
+	return getMethodDesc(config, config.Call)
+}
+
+func getMethodDesc(config *config.Config, call string) (*desc.MethodDescriptor, error) {
 	if config.Proto != "" {
-		return protodesc.GetMethodDescFromProto(config.Call, config.Proto, config.ImportPaths)
+		return protodesc.GetMethodDescFromProtos(call, config.ProtoFiles(), config.ImportPaths)
+	}
+
+	if config.Protoset != "" {
+		return protodesc.GetMethodDescFromProtoSet(call, config.Protoset)
+	}
+
+	reflector, err := newReflector(config)
+	if err != nil {
+		return nil, err
 	}
+	defer reflector.Close()
 
-	return protodesc.GetMethodDescFromProtoSet(config.Call, config.Protoset)
+	return reflector.MethodDesc(call)
+}
+
+// newReflector connects to the host of the config for server reflection
+func newReflector(config *config.Config) (*ghz.Reflector, error) {
+	return ghz.NewReflector(&ghz.Options{
+		Host:          config.Host,
+		Cert:          config.Cert,
+		CName:         config.CName,
+		Insecure:      config.Insecure,
+		Timeout:       config.Timeout,
+		DialTimtout:   config.DialTimeout,
+		KeepaliveTime: config.KeepaliveTime,
+		Metadata:      config.Metadata,
+	})
 }
@@ -8,12 +8,17 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/tab1293/ghz"
 )
 
+// The number of calls listed by default in the csv and html outputs
+const defaultDetails = 100000
+
 // Config for the run.
 type Config struct {
 	Proto         string             `json:"proto"`
@@ -29,19 +34,106 @@ type Config struct {
 	Timeout       int                `json:"t"`
 	Data          interface{}        `json:"d,omitempty"`
 	DataPath      string             `json:"D"`
+	BinaryPath    string             `json:"B,omitempty"`
+	DataFormat    string             `json:"dataFormat,omitempty"`
+	DataMode      string             `json:"dataMode,omitempty"`
+	CSV           string             `json:"csv,omitempty"`
+	CSVMode       string             `json:"csvMode,omitempty"`
+	CSVEnd        string             `json:"csvEnd,omitempty"`
+	Random        bool               `json:"random,omitempty"`
+	RandomLength  int                `json:"randomLength,omitempty"`
+	RandomRepeat  int                `json:"randomRepeated,omitempty"`
+	RandomDepth   int                `json:"randomDepth,omitempty"`
+	Seed          int64              `json:"seed,omitempty"`
+	Record        string             `json:"record,omitempty"`
+	RecordFormat  string             `json:"recordFormat,omitempty"`
+	RecordEvery   int                `json:"recordEvery,omitempty"`
+	RecordErrors  bool               `json:"recordErrors,omitempty"`
+	RecordMax     int                `json:"recordMax,omitempty"`
 	Metadata      *map[string]string `json:"m,omitempty"`
 	MetadataPath  string             `json:"M"`
 	Output        string             `json:"o"`
 	Format        string             `json:"O"`
+	Table         string             `json:"table,omitempty"`
+	Details       int                `json:"details,omitempty"`
+	Precision     int                `json:"precision,omitempty"`
+	Percentiles   []float64          `json:"percentiles,omitempty"`
+	Buckets       string             `json:"buckets,omitempty"`
+	Interval      time.Duration      `json:"interval,omitempty"`
+	Progress      bool               `json:"progress,omitempty"`
+	Metrics       string             `json:"metrics,omitempty"`
+	MetricsLinger time.Duration      `json:"metricsLinger,omitempty"`
+	Measurement   string             `json:"measurement,omitempty"`
+	Tags          map[string]string  `json:"tags,omitempty"`
+	Thresholds    string             `json:"thresholds,omitempty"`
 	Host          string             `json:"host"`
 	DialTimeout   int                `json:"T"`
 	KeepaliveTime int                `json:"L"`
 	CPUs          int                `json:"cpus"`
 	ImportPaths   []string           `json:"i,omitempty"`
 	Insecure      bool               `json:"insecure,omitempty"`
+	OpenLoop      bool               `json:"openloop,omitempty"`
+	MaxInFlight   int                `json:"inflight,omitempty"`
+	Stages        []Stage            `json:"stages,omitempty"`
+	Search        string             `json:"search,omitempty"`
+	SearchBy      string             `json:"searchBy,omitempty"`
+	SearchStep    time.Duration      `json:"searchStep,omitempty"`
+	SLO           string             `json:"slo,omitempty"`
+	Calls         []Call             `json:"calls,omitempty"`
+	Steps         []Call             `json:"steps,omitempty"`
+	Assert        []Assertion        `json:"assert,omitempty"`
+}
+
+// Call is one of the calls of a weighted traffic mix or a step of a sequence.
+// The data, metadata and assertions default to the top level ones.
+type Call struct {
+	Name         string             `json:"name,omitempty"`
+	Call         string             `json:"call"`
+	Weight       int                `json:"weight,omitempty"`
+	Data         interface{}        `json:"d,omitempty"`
+	DataPath     string             `json:"D,omitempty"`
+	BinaryPath   string             `json:"B,omitempty"`
+	Metadata     *map[string]string `json:"m,omitempty"`
+	MetadataPath string             `json:"M,omitempty"`
+	Assert       []Assertion        `json:"assert,omitempty"`
+}
+
+// Assertion is a check of the outcome of every call: the expected status codes,
+// the value of a field of the response at a path such as $.items[0].name
+// or a regular expression it matches, and the number of messages of a server stream.
+type Assertion struct {
+	Name     string      `json:"name,omitempty"`
+	Status   []string    `json:"status,omitempty"`
+	Path     string      `json:"path,omitempty"`
+	Equals   interface{} `json:"equals,omitempty"`
+	Matches  string      `json:"matches,omitempty"`
+	Messages *int        `json:"messages,omitempty"`
+}
+
+// Stage is a single step of a multi-stage load profile.
+// It is the stage of the runner, so the stages are passed to it as they are.
+type Stage = ghz.Stage
+
+// Flags holds the values of the command line flags that are parsed
+// into the fields of the config rather than set as they are
+type Flags struct {
+	// The call data as JSON, read from stdin if it is @
+	Data string
+
+	// The request metadata as JSON
+	Metadata string
+
+	// The load stages in the <duration>:<qps>[:<concurrency>] format
+	Stages string
+
+	// The tags of the influx output in the <key>=<value> format
+	Tags string
+
+	// The comma separated list of the latency percentiles reported
+	Percentiles string
 }
 
-// New creates a new config
+// New creates a new config by all fields at once
 func New(proto, protoset, call, cert, cName string, n, c, qps int, z time.Duration, x time.Duration,
 	timeout int, data, dataPath, metadata, mdPath, output, format, host string,
 	dialTimout, keepaliveTime, cpus int, importPaths []string, insecure bool) (*Config, error) {
@@ -69,6 +161,13 @@ func New(proto, protoset, call, cert, cName string, n, c, qps int, z time.Durati
 		CPUs:          cpus,
 		Insecure:      insecure}
 
+	return NewWithFlags(cfg, Flags{Data: data, Metadata: metadata})
+}
+
+// NewWithFlags creates a new config from the one populated with the command
+// line flags and the flags parsed into it, with the defaults set and validated
+func NewWithFlags(cfg *Config, flags Flags) (*Config, error) {
+	data := flags.Data
 	if data == "@" {
 		b, err := ioutil.ReadAll(os.Stdin)
 		if err != nil {
@@ -82,7 +181,22 @@ func New(proto, protoset, call, cert, cName string, n, c, qps int, z time.Durati
 		return nil, err
 	}
 
-	err = cfg.setMetadata(metadata)
+	err = cfg.setStages(flags.Stages)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setMetadata(flags.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setTags(flags.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.setPercentiles(flags.Percentiles)
 	if err != nil {
 		return nil, err
 	}
@@ -118,31 +232,87 @@ func (c *Config) Default() {
 		c.DialTimeout = 10
 	}
 
+	if c.DataFormat == "" && c.isJSONL() {
+		c.DataFormat = "jsonl"
+	}
+
+	if c.DataFormat == "jsonl" && c.DataMode == "" {
+		c.DataMode = "roundrobin"
+	}
+
+	if strings.TrimSpace(c.CSV) != "" {
+		if c.CSVMode == "" {
+			c.CSVMode = "sequential"
+		}
+
+		if c.CSVEnd == "" {
+			c.CSVEnd = "wrap"
+		}
+	}
+
+	if strings.TrimSpace(c.Record) != "" && c.RecordFormat == "" {
+		c.RecordFormat = "jsonl"
+	}
+
+	// the html output and the details table of the csv output are built on the listing of the calls
+	if c.Details == 0 && (c.Format == "csv" && (c.Table == "" || c.Table == "details") || c.Format == "html") {
+		c.Details = defaultDetails
+	}
+
+	if strings.TrimSpace(c.Search) != "" {
+		if c.SearchBy == "" {
+			c.SearchBy = "qps"
+		}
+
+		if c.SearchStep == 0 {
+			c.SearchStep = 10 * time.Second
+		}
+	}
+
 	c.ImportPaths = append(c.ImportPaths, ".")
-	dir := filepath.Dir(c.Proto)
-	if dir != "." {
-		c.ImportPaths = append(c.ImportPaths, dir)
+	for _, proto := range c.ProtoFiles() {
+		if isDir(proto) {
+			// directories are added to the import paths when parsed
+			continue
+		}
+
+		dir := filepath.Dir(proto)
+		if dir != "." && !contains(c.ImportPaths, dir) {
+			c.ImportPaths = append(c.ImportPaths, dir)
+		}
 	}
 }
 
-// Validate the config
-func (c *Config) Validate() error {
-	if strings.TrimSpace(c.Proto) == "" && strings.TrimSpace(c.Protoset) == "" {
-		return errors.New("Proto or Protoset required")
+// ProtoFiles returns the comma separated list of proto files,
+// glob patterns and directories of proto files
+func (c *Config) ProtoFiles() []string {
+	var res []string
+	for _, proto := range strings.Split(c.Proto, ",") {
+		if proto = strings.TrimSpace(proto); proto != "" {
+			res = append(res, proto)
+		}
 	}
+	return res
+}
 
+// Validate the config
+func (c *Config) Validate() error {
 	if strings.TrimSpace(c.Proto) != "" {
-		if filepath.Ext(c.Proto) != ".proto" {
-			return errors.Errorf(fmt.Sprintf("proto: must have .proto extension"))
+		for _, proto := range c.ProtoFiles() {
+			if filepath.Ext(proto) != ".proto" && !isDir(proto) {
+				return errors.Errorf(fmt.Sprintf("proto: must have .proto extension"))
+			}
 		}
-	} else {
+	} else if strings.TrimSpace(c.Protoset) != "" {
 		if filepath.Ext(c.Protoset) != ".protoset" {
 			return errors.Errorf(fmt.Sprintf("protoset: must have .protoset extension"))
 		}
 	}
 
-	if err := requiredString(c.Call); err != nil {
-		return errors.Wrap(err, "call")
+	if len(c.Calls) == 0 && len(c.Steps) == 0 {
+		if err := requiredString(c.Call); err != nil {
+			return errors.Wrap(err, "call")
+		}
 	}
 
 	if err := minValue(c.N, 0); err != nil {
@@ -157,6 +327,16 @@ func (c *Config) Validate() error {
 		return errors.Wrap(err, "q")
 	}
 
+	if c.OpenLoop {
+		if err := minValue(c.QPS, 1); err != nil {
+			return errors.Wrap(err, "q")
+		}
+	}
+
+	if err := minValue(c.MaxInFlight, 0); err != nil {
+		return errors.Wrap(err, "inflight")
+	}
+
 	if err := minValue(c.Timeout, 0); err != nil {
 		return errors.Wrap(err, "t")
 	}
@@ -173,22 +353,209 @@ func (c *Config) Validate() error {
 		return errors.Wrap(err, "cpus")
 	}
 
-	if strings.TrimSpace(c.DataPath) == "" {
+	for i, s := range c.Stages {
+		if s.Duration <= 0 {
+			return errors.Errorf("stages: stage %d: duration must be positive", i+1)
+		}
+
+		for _, v := range []int{s.FromQPS, s.QPS, s.FromC, s.C} {
+			if err := minValue(v, 0); err != nil {
+				return errors.Wrapf(err, "stages: stage %d", i+1)
+			}
+		}
+	}
+
+	if strings.TrimSpace(c.DataPath) == "" && strings.TrimSpace(c.BinaryPath) == "" && !c.Random &&
+		len(c.Calls) == 0 && len(c.Steps) == 0 {
 		if c.Data == nil {
 			return errors.New("data: is required")
 		}
 	}
 
+	if len(c.Calls) > 0 && len(c.Steps) > 0 {
+		return errors.New("calls: cannot be used together with steps")
+	}
+
+	if c.DataFormat != "" && c.DataFormat != "json" && c.DataFormat != "jsonl" {
+		return errors.New("dataFormat: must be json or jsonl")
+	}
+
+	if c.DataFormat == "jsonl" {
+		if err := requiredString(c.DataPath); err != nil {
+			return errors.Wrap(err, "D")
+		}
+
+		if strings.TrimSpace(c.BinaryPath) != "" {
+			return errors.New("dataFormat: jsonl cannot be used with B")
+		}
+
+		if len(c.Calls) > 0 || len(c.Steps) > 0 {
+			return errors.New("dataFormat: jsonl cannot be used with calls or steps")
+		}
+	}
+
+	if c.DataMode != "" && c.DataMode != "roundrobin" && c.DataMode != "once" {
+		return errors.New("dataMode: must be roundrobin or once")
+	}
+
+	if c.Random {
+		if c.isJSONL() || strings.TrimSpace(c.BinaryPath) != "" {
+			return errors.New("random: cannot be used with jsonl data or B")
+		}
+
+		if err := minValue(c.RandomLength, 0); err != nil {
+			return errors.Wrap(err, "randomLength")
+		}
+
+		if err := minValue(c.RandomRepeat, 0); err != nil {
+			return errors.Wrap(err, "randomRepeated")
+		}
+
+		if err := minValue(c.RandomDepth, 0); err != nil {
+			return errors.Wrap(err, "randomDepth")
+		}
+	}
+
+	if c.CSVMode != "" && c.CSVMode != "sequential" && c.CSVMode != "random" && c.CSVMode != "partition" {
+		return errors.New("csvMode: must be sequential, random or partition")
+	}
+
+	if c.CSVEnd != "" && c.CSVEnd != "wrap" && c.CSVEnd != "stop" {
+		return errors.New("csvEnd: must be wrap or stop")
+	}
+
+	switch c.Table {
+	case "", "details", "percentiles", "histogram", "status", "intervals":
+	default:
+		return errors.New("table: must be details, percentiles, histogram, status or intervals")
+	}
+
+	if c.RecordFormat != "" && c.RecordFormat != "jsonl" && c.RecordFormat != "bin" {
+		return errors.New("recordFormat: must be jsonl or bin")
+	}
+
+	if err := minValue(c.RecordEvery, 0); err != nil {
+		return errors.Wrap(err, "recordEvery")
+	}
+
+	if err := minValue(c.RecordMax, 0); err != nil {
+		return errors.Wrap(err, "recordMax")
+	}
+
+	if err := minValue(c.Details, 0); err != nil {
+		return errors.Wrap(err, "details")
+	}
+
+	if c.Precision != 0 && (c.Precision < 1 || c.Precision > 5) {
+		return errors.New("precision: must be between 1 and 5")
+	}
+
+	for i, p := range c.Percentiles {
+		if p <= 0 || p > 100 || i > 0 && p <= c.Percentiles[i-1] {
+			return errors.New("percentiles: must be above 0 and up to 100, in increasing order")
+		}
+	}
+
+	if c.Interval < 0 {
+		return errors.New("interval: must not be negative")
+	}
+
+	if c.MetricsLinger < 0 {
+		return errors.New("metricsLinger: must not be negative")
+	}
+
+	if err := validateCalls(c.Calls, "calls: call", c.Random); err != nil {
+		return err
+	}
+
+	if err := validateCalls(c.Steps, "steps: step", c.Random); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool, len(c.Steps))
+	for i, step := range c.Steps {
+		name := step.Name
+		if name == "" {
+			name = step.Call
+		}
+
+		if names[name] {
+			return errors.Errorf("steps: step %d: duplicate name %q", i+1, name)
+		}
+		names[name] = true
+	}
+
+	if strings.TrimSpace(c.Search) != "" {
+		if _, _, err := c.SearchRange(); err != nil {
+			return errors.Wrap(err, "search")
+		}
+
+		if c.SearchBy != "qps" && c.SearchBy != "c" {
+			return errors.New("searchBy: must be qps or c")
+		}
+
+		if c.SearchStep <= 0 {
+			return errors.New("searchStep: must be positive")
+		}
+
+		if err := requiredString(c.SLO); err != nil {
+			return errors.Wrap(err, "slo")
+		}
+
+		if strings.TrimSpace(c.Record) != "" {
+			return errors.New("record: cannot be used with search")
+		}
+
+		if c.MaxInFlight > 0 {
+			return errors.New("inflight: cannot be used with search")
+		}
+	}
+
+	return nil
+}
+
+func validateCalls(calls []Call, label string, random bool) error {
+	for i, call := range calls {
+		if err := requiredString(call.Call); err != nil {
+			return errors.Wrapf(err, "%s %d: call", label, i+1)
+		}
+
+		if err := minValue(call.Weight, 0); err != nil {
+			return errors.Wrapf(err, "%s %d: weight", label, i+1)
+		}
+
+		if call.Data == nil && strings.TrimSpace(call.BinaryPath) == "" && !random {
+			return errors.Errorf("%s %d: data: is required", label, i+1)
+		}
+	}
+
 	return nil
 }
 
+// SearchRange returns the load range of the saturation search
+func (c *Config) SearchRange() (int, int, error) {
+	min, max, err := parseRange(c.Search)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if min < 1 || max <= min {
+		return 0, 0, errors.Errorf("invalid range %q", c.Search)
+	}
+
+	return min, max, nil
+}
+
 // UnmarshalJSON is our custom implementation to handle the Duration field Z
 // and validate data
 func (c *Config) UnmarshalJSON(data []byte) error {
 	type Alias Config
 	aux := &struct {
-		Z string `json:"z"`
-		X string `json:"x"`
+		Z          string `json:"z"`
+		X          string `json:"x"`
+		SearchStep string `json:"searchStep"`
+		Interval   string `json:"interval"`
+		Linger     string `json:"metricsLinger"`
 		*Alias
 	}{
 		Alias: (*Alias)(c),
@@ -197,6 +564,30 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if aux.SearchStep != "" {
+		searchStep, err := time.ParseDuration(aux.SearchStep)
+		if err != nil {
+			return errors.Wrap(err, "searchStep")
+		}
+		c.SearchStep = searchStep
+	}
+
+	if aux.Interval != "" {
+		interval, err := time.ParseDuration(aux.Interval)
+		if err != nil {
+			return errors.Wrap(err, "interval")
+		}
+		c.Interval = interval
+	}
+
+	if aux.Linger != "" {
+		linger, err := time.ParseDuration(aux.Linger)
+		if err != nil {
+			return errors.Wrap(err, "metricsLinger")
+		}
+		c.MetricsLinger = linger
+	}
+
 	if aux.Data != nil {
 		err := checkData(aux.Data)
 		if err != nil {
@@ -211,13 +602,34 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 // MarshalJSON is our custom implementation to handle the Duration field Z
 func (c Config) MarshalJSON() ([]byte, error) {
 	type Alias Config
+	var searchStep string
+	if c.SearchStep > 0 {
+		searchStep = c.SearchStep.String()
+	}
+
+	var interval string
+	if c.Interval > 0 {
+		interval = c.Interval.String()
+	}
+
+	var linger string
+	if c.MetricsLinger > 0 {
+		linger = c.MetricsLinger.String()
+	}
+
 	return json.Marshal(&struct {
 		*Alias
-		Z string `json:"z"`
-		X string `json:"x"`
+		Z          string `json:"z"`
+		X          string `json:"x"`
+		SearchStep string `json:"searchStep,omitempty"`
+		Interval   string `json:"interval,omitempty"`
+		Linger     string `json:"metricsLinger,omitempty"`
 	}{
-		Alias: (*Alias)(&c),
-		Z:     c.Z.String(),
+		Alias:      (*Alias)(&c),
+		Z:          c.Z.String(),
+		SearchStep: searchStep,
+		Interval:   interval,
+		Linger:     linger,
 	})
 }
 
@@ -235,6 +647,12 @@ func ReadConfig(path string) (*Config, error) {
 func (c *Config) initData() error {
 	if c.Data != nil {
 		return nil
+	} else if c.isJSONL() {
+		// the data is streamed from the file during the run
+		return nil
+	} else if strings.TrimSpace(c.BinaryPath) != "" {
+		// the binary data is read as is
+		return nil
 	} else if strings.TrimSpace(c.DataPath) != "" {
 		d, err := ioutil.ReadFile(c.DataPath)
 		if err != nil {
@@ -242,11 +660,86 @@ func (c *Config) initData() error {
 		}
 
 		return json.Unmarshal(d, &c.Data)
+	} else if len(c.Calls) > 0 || len(c.Steps) > 0 || c.Random {
+		return nil
 	}
 
 	return errors.New("No data specified")
 }
 
+// initCalls loads the data and metadata of the calls of the traffic mix
+// and the steps of the sequence, defaulting to the top level data and metadata
+func (c *Config) initCalls() error {
+	for i := range c.Calls {
+		if err := c.initCall(&c.Calls[i]); err != nil {
+			return errors.Wrapf(err, "calls: call %d", i+1)
+		}
+	}
+
+	for i := range c.Steps {
+		if err := c.initCall(&c.Steps[i]); err != nil {
+			return errors.Wrapf(err, "steps: step %d", i+1)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) initCall(call *Call) error {
+	if call.Data == nil && strings.TrimSpace(call.DataPath) != "" {
+		d, err := ioutil.ReadFile(call.DataPath)
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(d, &call.Data); err != nil {
+			return err
+		}
+	}
+
+	if call.Data == nil && strings.TrimSpace(call.BinaryPath) == "" {
+		call.Data = c.Data
+		call.BinaryPath = c.BinaryPath
+	}
+
+	if call.Data != nil {
+		if err := checkData(call.Data); err != nil {
+			return err
+		}
+	}
+
+	if call.Metadata == nil && strings.TrimSpace(call.MetadataPath) != "" {
+		d, err := ioutil.ReadFile(call.MetadataPath)
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(d, &call.Metadata); err != nil {
+			return err
+		}
+	}
+
+	if call.Metadata == nil {
+		call.Metadata = c.Metadata
+	}
+
+	if call.Assert == nil {
+		call.Assert = c.Assert
+	}
+
+	return nil
+}
+
+// isJSONL returns whether the data file is in the JSON Lines format,
+// either set explicitly or by the .jsonl extension
+func (c *Config) isJSONL() bool {
+	if c.DataFormat != "" {
+		return c.DataFormat == "jsonl"
+	}
+
+	return strings.TrimSpace(c.DataPath) != "" && filepath.Ext(c.DataPath) == ".jsonl"
+}
+
 // SetData sets data based on input JSON string
 func (c *Config) setData(in string) error {
 	if strings.TrimSpace(in) != "" {
@@ -263,6 +756,51 @@ func (c *Config) setMetadata(in string) error {
 	return nil
 }
 
+// setTags sets the tags of the influx output based on the input string
+func (c *Config) setTags(in string) error {
+	if strings.TrimSpace(in) == "" {
+		return nil
+	}
+
+	tags, err := parseTags(in)
+	if err != nil {
+		return errors.Wrap(err, "tags")
+	}
+
+	c.Tags = tags
+	return nil
+}
+
+// setPercentiles sets the latency percentiles based on the input string
+func (c *Config) setPercentiles(in string) error {
+	if strings.TrimSpace(in) == "" {
+		return nil
+	}
+
+	pctls, err := ghz.ParsePercentiles(in)
+	if err != nil {
+		return errors.Wrap(err, "percentiles")
+	}
+
+	c.Percentiles = pctls
+	return nil
+}
+
+// setStages sets the load profile stages based on the input string
+func (c *Config) setStages(in string) error {
+	if strings.TrimSpace(in) == "" {
+		return nil
+	}
+
+	stages, err := parseStages(in)
+	if err != nil {
+		return errors.Wrap(err, "stages")
+	}
+
+	c.Stages = stages
+	return nil
+}
+
 // InitMetadata returns the payload data
 func (c *Config) initMetadata() error {
 	if c.Metadata != nil && len(*c.Metadata) > 0 {
@@ -298,6 +836,11 @@ func (c *Config) init() error {
 		return err
 	}
 
+	err = c.initCalls()
+	if err != nil {
+		return err
+	}
+
 	c.initDurations()
 
 	c.Default()
@@ -332,6 +875,89 @@ func checkData(data interface{}) error {
 	return nil
 }
 
+// parseStages parses a comma separated list of stages in the
+// <duration>:<qps>[:<concurrency>] format, where qps and concurrency are
+// either a single value or a <from>-<to> range. For example:
+// 30s:10-500,5m:500,30s:500-0 or 1m:0:10-100
+func parseStages(in string) ([]Stage, error) {
+	var stages []Stage
+	for i, part := range strings.Split(in, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, errors.Errorf("stage %d: expected <duration>:<qps>[:<concurrency>], got %q", i+1, part)
+		}
+
+		d, err := time.ParseDuration(fields[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "stage %d", i+1)
+		}
+
+		s := Stage{Duration: d}
+
+		s.FromQPS, s.QPS, err = parseRange(fields[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "stage %d: qps", i+1)
+		}
+
+		if len(fields) == 3 {
+			s.FromC, s.C, err = parseRange(fields[2])
+			if err != nil {
+				return nil, errors.Wrapf(err, "stage %d: concurrency", i+1)
+			}
+		}
+
+		stages = append(stages, s)
+	}
+
+	return stages, nil
+}
+
+// parseTags parses a comma separated list of tags in the <key>=<value>
+// format, such as test=checkout,sha=1a2b3c,env=staging
+func parseTags(in string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(in, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, errors.Errorf("expected <key>=<value>, got %q", part)
+		}
+
+		tags[kv[0]] = kv[1]
+	}
+
+	return tags, nil
+}
+
+// parseRange parses either a single value or a <from>-<to> range.
+// An empty value is 0. For a single value from is 0.
+func parseRange(in string) (int, int, error) {
+	in = strings.TrimSpace(in)
+	if in == "" {
+		return 0, 0, nil
+	}
+
+	parts := strings.Split(in, "-")
+	if len(parts) > 2 {
+		return 0, 0, errors.Errorf("invalid range %q", in)
+	}
+
+	to, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid value %q", in)
+	}
+
+	if len(parts) == 1 {
+		return 0, to, nil
+	}
+
+	from, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid value %q", in)
+	}
+
+	return from, to, nil
+}
+
 // RequiredString checks if the required string is empty
 func requiredString(s string) error {
 	if strings.TrimSpace(s) == "" {
@@ -367,3 +993,17 @@ func parseConfig(b []byte) (*Config, error) {
 
 	return c, nil
 }
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -329,10 +329,10 @@ func TestConfig_ReadConfig(t *testing.T) {
 }
 
 func TestConfig_Validate(t *testing.T) {
-	t.Run("missing proto", func(t *testing.T) {
-		c := &Config{}
+	t.Run("missing proto uses reflection", func(t *testing.T) {
+		c := &Config{Call: "a.b.c", Data: "asdf"}
 		err := c.Validate()
-		assert.Equal(t, "Proto or Protoset required", err.Error())
+		assert.NoError(t, err)
 	})
 
 	t.Run("invalid proto", func(t *testing.T) {
@@ -365,6 +365,25 @@ func TestConfig_Validate(t *testing.T) {
 		assert.Equal(t, "q: must be at least 0", err.Error())
 	})
 
+	t.Run("open loop without QPS", func(t *testing.T) {
+		c := &Config{Proto: "asdf.proto", Call: "call", Cert: "cert", OpenLoop: true}
+		err := c.Validate()
+		assert.Equal(t, "q: must be at least 1", err.Error())
+	})
+
+	t.Run("inflight < 0", func(t *testing.T) {
+		c := &Config{Proto: "asdf.proto", Call: "call", Cert: "cert", MaxInFlight: -1}
+		err := c.Validate()
+		assert.Equal(t, "inflight: must be at least 0", err.Error())
+	})
+
+	t.Run("inflight with search", func(t *testing.T) {
+		c := &Config{Proto: "asdf.proto", Call: "call", Cert: "cert", DataPath: "asdf", MaxInFlight: 10,
+			Search: "10-100", SearchBy: "qps", SearchStep: time.Second, SLO: "p99<50ms"}
+		err := c.Validate()
+		assert.Equal(t, "inflight: cannot be used with search", err.Error())
+	})
+
 	t.Run("T < 0", func(t *testing.T) {
 		c := &Config{Proto: "asdf.proto", Call: "call", Cert: "cert", Timeout: -1}
 		err := c.Validate()
@@ -390,6 +409,220 @@ func TestConfig_Validate(t *testing.T) {
 	})
 }
 
+func TestConfig_parseStages(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		stages, err := parseStages("30s:10-500, 5m:500,30s:500-10:20,1m:0:10-100,1m::5")
+		assert.NoError(t, err)
+		assert.Equal(t, []Stage{
+			{Duration: 30 * time.Second, FromQPS: 10, QPS: 500},
+			{Duration: 5 * time.Minute, QPS: 500},
+			{Duration: 30 * time.Second, FromQPS: 500, QPS: 10, C: 20},
+			{Duration: time.Minute, FromC: 10, C: 100},
+			{Duration: time.Minute, C: 5},
+		}, stages)
+	})
+
+	var tests = []struct {
+		name string
+		in   string
+	}{
+		{"missing qps", "30s"},
+		{"too many fields", "30s:1:2:3"},
+		{"invalid duration", "foo:10"},
+		{"invalid qps", "30s:abc"},
+		{"invalid range", "30s:1-2-3"},
+		{"invalid concurrency", "30s:10:x-5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := parseStages(tt.in)
+			assert.Error(t, err)
+			assert.Nil(t, stages)
+		})
+	}
+}
+
+func TestConfig_Stages(t *testing.T) {
+	t.Run("unmarshal", func(t *testing.T) {
+		jsonStr := `{"proto":"pf", "call":"sc", "d":{}, "stages":[{"duration":"30s","fromQ":10,"q":500},{"duration":"5m","c":20}]}`
+		c := Config{}
+		err := json.Unmarshal([]byte(jsonStr), &c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Stage{
+			{Duration: 30 * time.Second, FromQPS: 10, QPS: 500},
+			{Duration: 5 * time.Minute, C: 20},
+		}, c.Stages)
+	})
+
+	t.Run("unmarshal invalid duration", func(t *testing.T) {
+		jsonStr := `{"proto":"pf", "call":"sc", "d":{}, "stages":[{"duration":"asdf","q":500}]}`
+		c := Config{}
+		err := json.Unmarshal([]byte(jsonStr), &c)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		s := Stage{Duration: 30 * time.Second, FromQPS: 10, QPS: 500}
+		sJSON, err := json.Marshal(&s)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"duration":"30s","fromQ":10,"q":500}`, string(sJSON))
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		c := &Config{Proto: "asdf.proto", Call: "call", DataPath: "asdf", Stages: []Stage{{QPS: 10}}}
+		err := c.Validate()
+		assert.Equal(t, "stages: stage 1: duration must be positive", err.Error())
+
+		c.Stages = []Stage{{Duration: time.Second, QPS: -10}}
+		err = c.Validate()
+		assert.Equal(t, "stages: stage 1: must be at least 0", err.Error())
+	})
+}
+
+func TestConfig_Calls(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		jsonStr := `{"proto":"my.proto", "d":{"name":"joe"}, "m":{"token":"abc"},
+			"calls":[{"call":"a.B.C","weight":70},{"name":"other","call":"a.B.D","weight":30,"d":{"name":"bob"}}]}`
+		c, err := parseConfigString(jsonStr)
+
+		assert.NoError(t, err)
+		assert.Len(t, c.Calls, 2)
+		assert.Equal(t, "a.B.C", c.Calls[0].Call)
+		assert.Equal(t, 70, c.Calls[0].Weight)
+		assert.Equal(t, map[string]interface{}{"name": "joe"}, c.Calls[0].Data)
+		assert.Equal(t, &map[string]string{"token": "abc"}, c.Calls[0].Metadata)
+		assert.Equal(t, "other", c.Calls[1].Name)
+		assert.Equal(t, map[string]interface{}{"name": "bob"}, c.Calls[1].Data)
+		assert.Equal(t, &map[string]string{"token": "abc"}, c.Calls[1].Metadata)
+	})
+
+	t.Run("with data file", func(t *testing.T) {
+		jsonStr := `{"proto":"my.proto", "calls":[{"call":"a.B.C","D":"../testdata/data.json"}]}`
+		c, err := parseConfigString(jsonStr)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, c.Calls[0].Data)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "calls":[{"call":"a.B.C"}]}`)
+		assert.Equal(t, "calls: call 1: data: is required", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "d":{}, "calls":[{"weight":1}]}`)
+		assert.Equal(t, "calls: call 1: call: is required", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "d":{}, "calls":[{"call":"a.B.C","weight":-1}]}`)
+		assert.Equal(t, "calls: call 1: weight: must be at least 0", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "calls":[{"call":"a.B.C","d":"asdf"}]}`)
+		assert.Equal(t, "calls: call 1: Unsupported type for Data", err.Error())
+	})
+}
+
+func TestConfig_Assert(t *testing.T) {
+	jsonStr := `{"proto":"my.proto", "d":{"name":"joe"},
+		"assert":[{"status":["OK"]},{"name":"greeting","path":"$.message","matches":"^Hello"}],
+		"calls":[{"call":"a.B.C"},{"call":"a.B.D","assert":[{"path":"$.items","messages":3}]}]}`
+	c, err := parseConfigString(jsonStr)
+
+	three := 3
+	assert.NoError(t, err)
+	assert.Equal(t, []Assertion{
+		{Status: []string{"OK"}},
+		{Name: "greeting", Path: "$.message", Matches: "^Hello"},
+	}, c.Assert)
+	assert.Equal(t, c.Assert, c.Calls[0].Assert)
+	assert.Equal(t, []Assertion{{Path: "$.items", Messages: &three}}, c.Calls[1].Assert)
+}
+
+func TestConfig_Steps(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		jsonStr := `{"proto":"my.proto", "d":{"name":"joe"},
+			"steps":[{"name":"login","call":"a.B.Login"},{"call":"a.B.Use","d":{"token":"{{ .Steps.login.response.token }}"}}]}`
+		c, err := parseConfigString(jsonStr)
+
+		assert.NoError(t, err)
+		assert.Len(t, c.Steps, 2)
+		assert.Equal(t, "login", c.Steps[0].Name)
+		assert.Equal(t, map[string]interface{}{"name": "joe"}, c.Steps[0].Data)
+		assert.Equal(t, "a.B.Use", c.Steps[1].Call)
+		assert.Equal(t, map[string]interface{}{"token": "{{ .Steps.login.response.token }}"}, c.Steps[1].Data)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "d":{}, "steps":[{"call":"a.B.C"}], "calls":[{"call":"a.B.C"}]}`)
+		assert.Equal(t, "calls: cannot be used together with steps", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "steps":[{"call":"a.B.C"}]}`)
+		assert.Equal(t, "steps: step 1: data: is required", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "d":{}, "steps":[{"call":"a.B.C"},{"call":"a.B.C"}]}`)
+		assert.Equal(t, `steps: step 2: duplicate name "a.B.C"`, err.Error())
+	})
+}
+
+func TestConfig_DataFormat(t *testing.T) {
+	t.Run("jsonl by extension", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "D":"../testdata/data.jsonl"}`)
+
+		assert.NoError(t, err)
+		assert.Nil(t, c.Data)
+		assert.Equal(t, "jsonl", c.DataFormat)
+		assert.Equal(t, "roundrobin", c.DataMode)
+	})
+
+	t.Run("jsonl explicitly", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "D":"requests.txt", "dataFormat":"jsonl", "dataMode":"once"}`)
+
+		assert.NoError(t, err)
+		assert.Nil(t, c.Data)
+		assert.Equal(t, "jsonl", c.DataFormat)
+		assert.Equal(t, "once", c.DataMode)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "D":"../testdata/data.json"}`)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, c.Data)
+		assert.Equal(t, "", c.DataFormat)
+		assert.Equal(t, "", c.DataMode)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "dataFormat":"xml"}`)
+		assert.Equal(t, "dataFormat: must be json or jsonl", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "dataFormat":"jsonl"}`)
+		assert.Equal(t, "D: is required", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "D":"../testdata/data.jsonl", "dataMode":"random"}`)
+		assert.Equal(t, "dataMode: must be roundrobin or once", err.Error())
+	})
+}
+
+func TestConfig_CSV(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "csv":"users.csv"}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "users.csv", c.CSV)
+		assert.Equal(t, "sequential", c.CSVMode)
+		assert.Equal(t, "wrap", c.CSVEnd)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "csv":"users.csv", "csvMode":"shuffle"}`)
+		assert.Equal(t, "csvMode: must be sequential, random or partition", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "csv":"users.csv", "csvEnd":"rewind"}`)
+		assert.Equal(t, "csvEnd: must be wrap or stop", err.Error())
+	})
+}
+
 func TestConfig_initData(t *testing.T) {
 	t.Run("when empty", func(t *testing.T) {
 		c := &Config{}
@@ -447,3 +680,251 @@ func TestConfig_initDurations(t *testing.T) {
 		assert.Equal(t, c.Z, dur)
 	})
 }
+
+func TestConfig_BinaryPath(t *testing.T) {
+	t.Run("no data required", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "B":"data.bin"}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "data.bin", c.BinaryPath)
+		assert.Nil(t, c.Data)
+	})
+
+	t.Run("calls default to binary path", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "B":"data.bin",
+			"calls":[{"call":"a.B.C"}, {"call":"a.B.D", "d":{"name":"bob"}}]}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "data.bin", c.Calls[0].BinaryPath)
+		assert.Equal(t, "", c.Calls[1].BinaryPath)
+		assert.Equal(t, map[string]interface{}{"name": "bob"}, c.Calls[1].Data)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "B":"data.bin", "D":"data.jsonl"}`)
+		assert.Equal(t, "dataFormat: jsonl cannot be used with B", err.Error())
+	})
+}
+
+func TestConfig_Random(t *testing.T) {
+	t.Run("no data required", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "random":true, "randomLength":100}`)
+
+		assert.NoError(t, err)
+		assert.True(t, c.Random)
+		assert.Equal(t, 100, c.RandomLength)
+		assert.Nil(t, c.Data)
+
+		_, err = parseConfigString(`{"proto":"my.proto", "random":true, "calls":[{"call":"a.B.C"}]}`)
+		assert.NoError(t, err)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "random":true, "randomDepth":-1}`)
+		assert.Equal(t, "randomDepth: must be at least 0", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "random":true, "B":"data.bin"}`)
+		assert.Equal(t, "random: cannot be used with jsonl data or B", err.Error())
+	})
+}
+
+func TestConfig_Percentiles(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "percentiles":[50, 99, 99.9]}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{50, 99, 99.9}, c.Percentiles)
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		c, err := NewWithFlags(&Config{Proto: "my.proto", Call: "a.B.C"}, Flags{Data: "{}", Percentiles: "99.9, p50,99"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{50, 99, 99.9}, c.Percentiles)
+
+		_, err = NewWithFlags(&Config{Proto: "my.proto", Call: "a.B.C"}, Flags{Data: "{}", Percentiles: "50,x"})
+		assert.Error(t, err)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "percentiles":"50,99"}`)
+		assert.Error(t, err)
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "percentiles":[50, 150]}`)
+		assert.Equal(t, "percentiles: must be above 0 and up to 100, in increasing order", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "percentiles":[99, 50]}`)
+		assert.Equal(t, "percentiles: must be above 0 and up to 100, in increasing order", err.Error())
+	})
+}
+
+func TestConfig_MetricsLinger(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "metrics":":9090", "metricsLinger":"30s"}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 30*time.Second, c.MetricsLinger)
+
+		cJSON, err := json.Marshal(c)
+		assert.NoError(t, err)
+		assert.Contains(t, string(cJSON), `"metricsLinger":"30s"`)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "metricsLinger":"soon"}`)
+		assert.Error(t, err)
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "metricsLinger":"-1s"}`)
+		assert.Equal(t, "metricsLinger: must not be negative", err.Error())
+	})
+}
+
+func TestConfig_Record(t *testing.T) {
+	t.Run("default format", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "record":"calls.jsonl", "recordEvery":10}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "calls.jsonl", c.Record)
+		assert.Equal(t, "jsonl", c.RecordFormat)
+		assert.Equal(t, 10, c.RecordEvery)
+
+		c, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "", c.RecordFormat)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "record":"calls", "recordFormat":"csv"}`)
+		assert.Equal(t, "recordFormat: must be jsonl or bin", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "record":"calls", "recordMax":-1}`)
+		assert.Equal(t, "recordMax: must be at least 0", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "record":"calls",
+			"search":"10-100", "slo":"p99<50ms"}`)
+		assert.Equal(t, "record: cannot be used with search", err.Error())
+	})
+}
+
+func TestConfig_ProtoFiles(t *testing.T) {
+	t.Run("multiple files", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"protos/a.proto, protos/b.proto,other/*.proto", "call":"a.B.C", "d":{}}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"protos/a.proto", "protos/b.proto", "other/*.proto"}, c.ProtoFiles())
+		assert.Equal(t, []string{".", "protos", "other"}, c.ImportPaths)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"../testdata/multi", "call":"a.B.C", "d":{}}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"../testdata/multi"}, c.ProtoFiles())
+		assert.Equal(t, []string{"."}, c.ImportPaths)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"a.proto,b.txt", "call":"a.B.C", "d":{}}`)
+		assert.Equal(t, "proto: must have .proto extension", err.Error())
+	})
+}
+
+func TestConfig_Details(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "O":"csv"}`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 100000, c.Details)
+
+		c, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "O":"json", "precision":4}`)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, c.Details)
+		assert.Equal(t, 4, c.Precision)
+
+		c, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "O":"html", "details":10}`)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, c.Details)
+
+		// only the details table of the csv output lists the calls
+		c, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "O":"csv", "table":"status"}`)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, c.Details)
+		assert.Equal(t, "status", c.Table)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		_, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "details":-1}`)
+		assert.Equal(t, "details: must be at least 0", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "precision":6}`)
+		assert.Equal(t, "precision: must be between 1 and 5", err.Error())
+
+		_, err = parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "O":"csv", "table":"errors"}`)
+		assert.Equal(t, "table: must be details, percentiles, histogram, status or intervals", err.Error())
+	})
+}
+
+func TestConfig_parseTags(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		tags, err := parseTags("test=checkout, sha=1a2b3c,env=a=b")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"test": "checkout", "sha": "1a2b3c", "env": "a=b"}, tags)
+	})
+
+	var tests = []struct {
+		name string
+		in   string
+	}{
+		{"missing value", "test"},
+		{"empty value", "test="},
+		{"empty key", "=checkout"},
+		{"empty tag", "test=checkout,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := parseTags(tt.in)
+			assert.Error(t, err)
+			assert.Nil(t, tags)
+		})
+	}
+}
+
+func TestConfig_Tags(t *testing.T) {
+	c, err := parseConfigString(`{"proto":"my.proto", "call":"a.B.C", "d":{}, "O":"influx", "measurement":"bench", "tags":{"env":"staging"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "bench", c.Measurement)
+	assert.Equal(t, map[string]string{"env": "staging"}, c.Tags)
+}
+
+func TestConfig_New(t *testing.T) {
+	c, err := New("my.proto", "", "a.B.C", "", "", 100, 0, 0, 0, 0, 0, `{"name":"bob"}`, "", `{"trace":"1"}`,
+		"", "", "", "localhost:50051", 0, 0, 0, nil, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100, c.N)
+	assert.Equal(t, 50, c.C)
+	assert.True(t, c.Insecure)
+	assert.Equal(t, map[string]interface{}{"name": "bob"}, c.Data)
+	assert.Equal(t, &map[string]string{"trace": "1"}, c.Metadata)
+
+	_, err = New("my.proto", "", "a.B.C", "", "", 100, 0, 0, 0, 0, 0, `{"name":`, "", "",
+		"", "", "", "localhost:50051", 0, 0, 0, nil, false)
+	assert.Error(t, err)
+}
+
+func TestConfig_NewWithFlags(t *testing.T) {
+	c, err := NewWithFlags(&Config{Proto: "my.proto", Call: "a.B.C", Host: "localhost:50051", N: 100},
+		Flags{Data: `{"name":"bob"}`, Metadata: `{"trace":"1"}`, Stages: "10s:10-100", Tags: "env=staging"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100, c.N)
+	assert.Equal(t, 50, c.C)
+	assert.Equal(t, map[string]interface{}{"name": "bob"}, c.Data)
+	assert.Equal(t, &map[string]string{"trace": "1"}, c.Metadata)
+	assert.Equal(t, []Stage{{Duration: 10 * time.Second, FromQPS: 10, QPS: 100}}, c.Stages)
+	assert.Equal(t, map[string]string{"env": "staging"}, c.Tags)
+
+	_, err = NewWithFlags(&Config{Proto: "my.proto", Call: "a.B.C"}, Flags{Data: "{}", Stages: "10s"})
+	assert.Error(t, err)
+}
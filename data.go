@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/dynamic"
 )
@@ -48,6 +49,29 @@ func messageFromMap(input *dynamic.Message, data *map[string]interface{}) error
 	return nil
 }
 
+// creates a map from a message
+// marshal to JSON using jsonpb with the original field names then unmarshal to a map
+// so the fields can be used in templates the same way as they appear in the proto.
+func messageToMap(msg proto.Message) (map[string]interface{}, error) {
+	if msg == nil {
+		return nil, nil
+	}
+
+	m := jsonpb.Marshaler{OrigName: true, EmitDefaults: true}
+	str, err := m.MarshalToString(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var res map[string]interface{}
+	err = json.Unmarshal([]byte(str), &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func createPayloads(data interface{}, mtd *desc.MethodDescriptor) (*dynamic.Message, *[]*dynamic.Message, error) {
 	md := mtd.GetInputType()
 	var input *dynamic.Message
@@ -94,3 +118,44 @@ func createPayloads(data interface{}, mtd *desc.MethodDescriptor) (*dynamic.Mess
 
 	return input, &streamInput, nil
 }
+
+// creates the payloads from serialized protobuf data
+// for client streaming the data is a stream of length-delimited messages,
+// each prefixed with its size as a varint, otherwise it is a single message.
+func createBinaryPayloads(data []byte, mtd *desc.MethodDescriptor) (*dynamic.Message, *[]*dynamic.Message, error) {
+	md := mtd.GetInputType()
+
+	if !mtd.IsClientStreaming() {
+		input := dynamic.NewMessage(md)
+		err := input.Unmarshal(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return input, &[]*dynamic.Message{}, nil
+	}
+
+	var streamInput []*dynamic.Message
+	for len(data) > 0 {
+		size, n := proto.DecodeVarint(data)
+		if n == 0 {
+			return nil, nil, errors.New("invalid message size in binary data")
+		}
+
+		data = data[n:]
+		if uint64(len(data)) < size {
+			return nil, nil, errors.New("unexpected end of binary data")
+		}
+
+		elemMsg := dynamic.NewMessage(md)
+		err := elemMsg.Unmarshal(data[:size])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		streamInput = append(streamInput, elemMsg)
+		data = data[size:]
+	}
+
+	return nil, &streamInput, nil
+}
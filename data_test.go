@@ -3,6 +3,8 @@ package ghz
 import (
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/dynamic"
 	"github.com/tab1293/ghz/protodesc"
 
 	"github.com/stretchr/testify/assert"
@@ -329,3 +331,110 @@ func TestData_createPayloads(t *testing.T) {
 		assert.Empty(t, streaming)
 	})
 }
+
+func TestData_messageToMap(t *testing.T) {
+	mtd, err := protodesc.GetMethodDescFromProto(
+		"data.DataTestService.TestCallTwo",
+		"./testdata/data.proto",
+		nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, mtd)
+
+	t.Run("nil message", func(t *testing.T) {
+		m, err := messageToMap(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, m)
+	})
+
+	t.Run("uses the original field names", func(t *testing.T) {
+		msg := dynamic.NewMessage(mtd.GetOutputType())
+		msg.SetFieldByName("result_message", "bob")
+
+		m, err := messageToMap(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"result_message": "bob"}, m)
+	})
+
+	t.Run("includes default values", func(t *testing.T) {
+		msg := dynamic.NewMessage(mtd.GetInputType())
+
+		m, err := messageToMap(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"nested_prop": nil}, m)
+	})
+}
+
+func TestData_createBinaryPayloads(t *testing.T) {
+	mtdUnary, err := protodesc.GetMethodDescFromProto(
+		"helloworld.Greeter.SayHello",
+		"./testdata/greeter.proto",
+		nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, mtdUnary)
+
+	mtdClientStreaming, err := protodesc.GetMethodDescFromProto(
+		"helloworld.Greeter.SayHelloCS",
+		"./testdata/greeter.proto",
+		nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, mtdClientStreaming)
+
+	marshal := func(name string) []byte {
+		msg := dynamic.NewMessage(mtdUnary.GetInputType())
+		msg.SetFieldByName("name", name)
+		b, err := msg.Marshal()
+		assert.NoError(t, err)
+		return b
+	}
+
+	delimited := func(names ...string) []byte {
+		var res []byte
+		for _, name := range names {
+			b := marshal(name)
+			res = append(res, proto.EncodeVarint(uint64(len(b)))...)
+			res = append(res, b...)
+		}
+		return res
+	}
+
+	t.Run("create single object for unary", func(t *testing.T) {
+		single, streaming, err := createBinaryPayloads(marshal("bob"), mtdUnary)
+		assert.NoError(t, err)
+		assert.NotNil(t, single)
+		assert.Empty(t, *streaming)
+		assert.Equal(t, "bob", single.GetFieldByName("name"))
+	})
+
+	t.Run("fail for invalid data for unary", func(t *testing.T) {
+		single, streaming, err := createBinaryPayloads([]byte{0x0a, 0x05, 'b'}, mtdUnary)
+		assert.Error(t, err)
+		assert.Nil(t, single)
+		assert.Nil(t, streaming)
+	})
+
+	t.Run("create stream of messages for client streaming", func(t *testing.T) {
+		single, streaming, err := createBinaryPayloads(delimited("bob", "kate", "jim"), mtdClientStreaming)
+		assert.NoError(t, err)
+		assert.Nil(t, single)
+		assert.Len(t, *streaming, 3)
+		assert.Equal(t, "bob", (*streaming)[0].GetFieldByName("name"))
+		assert.Equal(t, "kate", (*streaming)[1].GetFieldByName("name"))
+		assert.Equal(t, "jim", (*streaming)[2].GetFieldByName("name"))
+	})
+
+	t.Run("fail for truncated stream", func(t *testing.T) {
+		data := delimited("bob", "kate")
+		single, streaming, err := createBinaryPayloads(data[:len(data)-2], mtdClientStreaming)
+		assert.EqualError(t, err, "unexpected end of binary data")
+		assert.Nil(t, single)
+		assert.Nil(t, streaming)
+
+		single, streaming, err = createBinaryPayloads([]byte{0x80}, mtdClientStreaming)
+		assert.EqualError(t, err, "invalid message size in binary data")
+		assert.Nil(t, single)
+		assert.Nil(t, streaming)
+	})
+}
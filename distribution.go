@@ -0,0 +1,175 @@
+package ghz
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The percentiles of the latency reported by default
+var defaultPercentiles = []float64{10, 25, 50, 75, 90, 95, 99}
+
+// Buckets is the layout of the buckets of the latency histogram.
+// Each bucket counts the calls slower than the mark of the previous one,
+// up to its own mark.
+type Buckets struct {
+	// "linear" for Count buckets of the same width from the fastest to the
+	// slowest call, "exponential" for Count buckets from the fastest to the
+	// slowest growing by the same factor, or "explicit" for the marks in Bounds
+	Layout string `json:"layout"`
+
+	// Number of buckets of the linear and exponential layouts, in addition to
+	// the one of the fastest call. Defaults to 10.
+	Count int `json:"count,omitempty"`
+
+	// The marks of the explicit layout in milliseconds, in increasing order.
+	// The calls slower than the last one are counted in a bucket of the slowest call.
+	Bounds []float64 `json:"bounds,omitempty"`
+}
+
+// ParsePercentiles parses a comma separated list of percentiles
+// such as 50,90,99.9, optionally prefixed with p as in p99.9
+func ParsePercentiles(in string) ([]float64, error) {
+	var res []float64
+	for _, s := range strings.Split(in, ",") {
+		s = strings.TrimPrefix(strings.TrimSpace(s), "p")
+		if s == "" {
+			continue
+		}
+
+		p, err := strconv.ParseFloat(s, 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("invalid percentile %q: must be a number above 0 and up to 100", s)
+		}
+
+		res = addPercentile(res, p)
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("percentiles are required")
+	}
+
+	return res, nil
+}
+
+// addPercentile adds the percentile to the sorted list unless it is already in it
+func addPercentile(pctls []float64, p float64) []float64 {
+	i := sort.SearchFloat64s(pctls, p)
+	if i < len(pctls) && pctls[i] == p {
+		return pctls
+	}
+
+	pctls = append(pctls, 0)
+	copy(pctls[i+1:], pctls[i:])
+	pctls[i] = p
+	return pctls
+}
+
+// ParseBuckets parses the layout of the histogram buckets: linear or
+// exponential optionally followed by the number of buckets as in
+// exponential:20, or a comma separated list of marks in milliseconds
+// such as 1,5,10,50
+func ParseBuckets(in string) (*Buckets, error) {
+	in = strings.TrimSpace(in)
+	layout, count := in, ""
+	if i := strings.IndexByte(in, ':'); i >= 0 {
+		layout, count = in[:i], in[i+1:]
+	}
+
+	if layout == "linear" || layout == "exponential" {
+		b := &Buckets{Layout: layout}
+		if count != "" {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid buckets %q: the number of buckets must be at least 1", in)
+			}
+			b.Count = n
+		}
+		return b, nil
+	}
+
+	b := &Buckets{Layout: "explicit"}
+	for _, s := range strings.Split(in, ",") {
+		mark, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid buckets %q: expected linear, exponential or a list of marks in ms", in)
+		}
+		b.Bounds = append(b.Bounds, mark)
+	}
+
+	if err := b.validate(); err != nil {
+		return nil, fmt.Errorf("invalid buckets %q: %v", in, err)
+	}
+
+	return b, nil
+}
+
+func (b *Buckets) validate() error {
+	switch b.Layout {
+	case "", "linear", "exponential":
+		if b.Count < 0 {
+			return errors.New("the number of buckets must not be negative")
+		}
+	case "explicit":
+		if len(b.Bounds) == 0 {
+			return errors.New("the marks of the buckets are required")
+		}
+		for i, mark := range b.Bounds {
+			if mark <= 0 {
+				return errors.New("the marks must be positive")
+			}
+			if i > 0 && mark <= b.Bounds[i-1] {
+				return errors.New("the marks must be in increasing order")
+			}
+		}
+	default:
+		return fmt.Errorf("unknown layout %q", b.Layout)
+	}
+
+	return nil
+}
+
+// marks returns the marks of the buckets in seconds
+// given the fastest and slowest latencies in seconds
+func (b *Buckets) marks(fastest, slowest float64) []float64 {
+	layout, count := "linear", 10
+	if b != nil {
+		if b.Layout != "" {
+			layout = b.Layout
+		}
+		if b.Count > 0 {
+			count = b.Count
+		}
+	}
+
+	if layout == "explicit" {
+		res := make([]float64, 0, len(b.Bounds)+1)
+		for _, mark := range b.Bounds {
+			res = append(res, mark/1000)
+		}
+		if slowest > res[len(res)-1] {
+			res = append(res, slowest)
+		}
+		return res
+	}
+
+	res := make([]float64, count+1)
+	if layout == "exponential" {
+		// the factor cannot be applied to sub-microsecond latencies
+		from := math.Max(fastest, 1e-6)
+		factor := math.Pow(math.Max(slowest, from)/from, 1/float64(count))
+		for i := 0; i < count; i++ {
+			res[i] = from * math.Pow(factor, float64(i))
+		}
+	} else {
+		bs := (slowest - fastest) / float64(count)
+		for i := 0; i < count; i++ {
+			res[i] = fastest + bs*float64(i)
+		}
+	}
+	res[count] = slowest
+	return res
+}
@@ -0,0 +1,100 @@
+package ghz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistribution_ParsePercentiles(t *testing.T) {
+	var tests = []struct {
+		in       string
+		expected []float64
+		err      string
+	}{
+		{"50,90,99", []float64{50, 90, 99}, ""},
+		{"p99.9, p50, 99.99", []float64{50, 99.9, 99.99}, ""},
+		{"99,50,99", []float64{50, 99}, ""},
+		{"100", []float64{100}, ""},
+		{"", nil, "percentiles are required"},
+		{"0", nil, `invalid percentile "0": must be a number above 0 and up to 100`},
+		{"50,101", nil, `invalid percentile "101": must be a number above 0 and up to 100`},
+		{"median", nil, `invalid percentile "median": must be a number above 0 and up to 100`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			pctls, err := ParsePercentiles(tt.in)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, pctls)
+		})
+	}
+}
+
+func TestDistribution_ParseBuckets(t *testing.T) {
+	var tests = []struct {
+		in       string
+		expected *Buckets
+		err      string
+	}{
+		{"linear", &Buckets{Layout: "linear"}, ""},
+		{"linear:20", &Buckets{Layout: "linear", Count: 20}, ""},
+		{"exponential:8", &Buckets{Layout: "exponential", Count: 8}, ""},
+		{"1, 5,10.5", &Buckets{Layout: "explicit", Bounds: []float64{1, 5, 10.5}}, ""},
+		{"linear:0", nil, `invalid buckets "linear:0": the number of buckets must be at least 1`},
+		{"log", nil, `invalid buckets "log": expected linear, exponential or a list of marks in ms`},
+		{"5,1", nil, `invalid buckets "5,1": the marks must be in increasing order`},
+		{"0,1", nil, `invalid buckets "0,1": the marks must be positive`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			b, err := ParseBuckets(tt.in)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, b)
+		})
+	}
+}
+
+func TestDistribution_marks(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		var b *Buckets
+		marks := b.marks(0.01, 0.02)
+		assert.Len(t, marks, 11)
+		assert.InDelta(t, 0.01, marks[0], 1e-9)
+		assert.InDelta(t, 0.015, marks[5], 1e-9)
+		assert.Equal(t, 0.02, marks[10])
+	})
+
+	t.Run("linear", func(t *testing.T) {
+		marks := (&Buckets{Layout: "linear", Count: 4}).marks(0.01, 0.05)
+		assert.Len(t, marks, 5)
+		assert.InDelta(t, 0.02, marks[1], 1e-9)
+		assert.Equal(t, 0.05, marks[4])
+	})
+
+	t.Run("exponential", func(t *testing.T) {
+		marks := (&Buckets{Layout: "exponential", Count: 3}).marks(0.001, 1)
+		assert.Len(t, marks, 4)
+		assert.InDelta(t, 0.001, marks[0], 1e-9)
+		assert.InDelta(t, 0.01, marks[1], 1e-9)
+		assert.InDelta(t, 0.1, marks[2], 1e-9)
+		assert.Equal(t, 1.0, marks[3])
+	})
+
+	t.Run("explicit", func(t *testing.T) {
+		b := &Buckets{Layout: "explicit", Bounds: []float64{1, 5, 10}}
+		assert.Equal(t, []float64{0.001, 0.005, 0.01, 0.02}, b.marks(0.0005, 0.02))
+		assert.Equal(t, []float64{0.001, 0.005, 0.01}, b.marks(0.0005, 0.008))
+	})
+}
@@ -0,0 +1,199 @@
+package ghz
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jsonlFeeder streams the data of every call from a JSON Lines file,
+// one message or array of messages per line, without loading the whole file.
+// In "roundrobin" mode it starts over at the end of the file,
+// in "once" mode every line is used once.
+type jsonlFeeder struct {
+	mu     sync.Mutex
+	path   string
+	once   bool
+	file   *os.File
+	reader *bufio.Reader
+
+	// number of lines handed out since the start of the file
+	lines int
+}
+
+func newJSONLFeeder(path, mode string) (*jsonlFeeder, error) {
+	if mode != "" && mode != "roundrobin" && mode != "once" {
+		return nil, fmt.Errorf("data mode must be \"roundrobin\" or \"once\", got %q", mode)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonlFeeder{
+		path:   path,
+		once:   mode == "once",
+		file:   file,
+		reader: bufio.NewReader(file)}, nil
+}
+
+// next returns the next line of data.
+// It returns io.EOF once all lines have been used in "once" mode.
+func (f *jsonlFeeder) next() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for {
+		line, err := f.reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			f.lines++
+			return line, nil
+		}
+
+		if err != io.EOF {
+			if err != nil {
+				return "", err
+			}
+			// skip empty lines
+			continue
+		}
+
+		if f.once {
+			return "", io.EOF
+		}
+
+		if f.lines == 0 {
+			return "", fmt.Errorf("no data in %s", f.path)
+		}
+
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		f.reader.Reset(f.file)
+		f.lines = 0
+	}
+}
+
+func (f *jsonlFeeder) close() error {
+	return f.file.Close()
+}
+
+// csvFeeder hands out the rows of a CSV file, each row as a map of the
+// values by the column names of the header row.
+// In "sequential" mode the rows are used in order, in "random" mode they are
+// picked at random and in "partition" mode each worker uses its own share of
+// the rows in order, so that no two workers use the same row.
+// At the end of the rows it starts over when end is "wrap", or returns io.EOF
+// when end is "stop".
+type csvFeeder struct {
+	mu   sync.Mutex
+	rows []map[string]string
+	mode string
+	stop bool
+	rand *rand.Rand
+
+	// position of the next row, and of every partition in "partition" mode
+	pos     int
+	partPos []int
+}
+
+func newCSVFeeder(path, mode, end string, partitions int) (*csvFeeder, error) {
+	switch mode {
+	case "":
+		mode = "sequential"
+	case "sequential", "random", "partition":
+	default:
+		return nil, fmt.Errorf("csv mode must be \"sequential\", \"random\" or \"partition\", got %q", mode)
+	}
+
+	if end != "" && end != "wrap" && end != "stop" {
+		return nil, fmt.Errorf("csv end must be \"wrap\" or \"stop\", got %q", end)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) < 2 {
+		return nil, fmt.Errorf("no rows in %s", path)
+	}
+
+	header := records[0]
+	rows := make([]map[string]string, len(records)-1)
+	for i, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for j, name := range header {
+			row[strings.TrimSpace(name)] = record[j]
+		}
+		rows[i] = row
+	}
+
+	if partitions < 1 {
+		partitions = 1
+	}
+
+	if mode == "partition" && partitions > len(rows) {
+		return nil, fmt.Errorf("%d rows in %s are not enough for %d partitions", len(rows), path, partitions)
+	}
+
+	return &csvFeeder{
+		rows:    rows,
+		mode:    mode,
+		stop:    end == "stop",
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		partPos: make([]int, partitions)}, nil
+}
+
+// next returns the row for the next call of the given 1-based worker,
+// 0 if the call was not made by a worker. In partition mode a call that was
+// not made by a worker uses the rows in order like in sequential mode, and
+// workers beyond the number of partitions share them, though the requester
+// never runs such calls in partition mode.
+// It returns io.EOF at the end of the rows when end is "stop".
+func (f *csvFeeder) next(worker int) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.mode == "random" {
+		return f.rows[f.rand.Intn(len(f.rows))], nil
+	}
+
+	if f.mode == "partition" && worker > 0 {
+		// of n partitions, partition p has the rows p, p+n, p+2n...
+		part := (worker - 1) % len(f.partPos)
+		idx := part + f.partPos[part]*len(f.partPos)
+		if idx >= len(f.rows) {
+			if f.stop {
+				return nil, io.EOF
+			}
+			f.partPos[part] = 0
+			idx = part
+		}
+		f.partPos[part]++
+		return f.rows[idx], nil
+	}
+
+	if f.pos >= len(f.rows) {
+		if f.stop {
+			return nil, io.EOF
+		}
+		f.pos = 0
+	}
+	row := f.rows[f.pos]
+	f.pos++
+	return row, nil
+}
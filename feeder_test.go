@@ -0,0 +1,179 @@
+package ghz
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLFeeder(t *testing.T) {
+	t.Run("invalid mode", func(t *testing.T) {
+		f, err := newJSONLFeeder("./testdata/data.jsonl", "random")
+		assert.Error(t, err)
+		assert.Nil(t, f)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		f, err := newJSONLFeeder("./testdata/missing.jsonl", "")
+		assert.Error(t, err)
+		assert.Nil(t, f)
+	})
+
+	t.Run("round robin", func(t *testing.T) {
+		f, err := newJSONLFeeder("./testdata/data.jsonl", "roundrobin")
+		assert.NoError(t, err)
+		defer f.close()
+
+		var lines []string
+		for i := 0; i < 5; i++ {
+			line, err := f.next()
+			assert.NoError(t, err)
+			lines = append(lines, line)
+		}
+
+		assert.Equal(t, []string{
+			`{"name":"bob"}`,
+			`{"name":"kate"}`,
+			`{"name":"joe"}`,
+			`{"name":"bob"}`,
+			`{"name":"kate"}`,
+		}, lines)
+	})
+
+	t.Run("once", func(t *testing.T) {
+		f, err := newJSONLFeeder("./testdata/data.jsonl", "once")
+		assert.NoError(t, err)
+		defer f.close()
+
+		for i := 0; i < 3; i++ {
+			_, err := f.next()
+			assert.NoError(t, err)
+		}
+
+		_, err = f.next()
+		assert.Equal(t, io.EOF, err)
+
+		_, err = f.next()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		file, err := ioutil.TempFile("", "ghz_feeder")
+		assert.NoError(t, err)
+		file.WriteString("\n\n")
+		file.Close()
+		defer os.Remove(file.Name())
+
+		f, err := newJSONLFeeder(file.Name(), "")
+		assert.NoError(t, err)
+		defer f.close()
+
+		_, err = f.next()
+		assert.Error(t, err)
+		assert.NotEqual(t, io.EOF, err)
+	})
+}
+
+func TestCSVFeeder(t *testing.T) {
+	userIDs := func(f *csvFeeder, worker, n int) []string {
+		var res []string
+		for i := 0; i < n; i++ {
+			row, err := f.next(worker)
+			if err != nil {
+				res = append(res, err.Error())
+				continue
+			}
+			res = append(res, row["user_id"])
+		}
+		return res
+	}
+
+	t.Run("invalid options", func(t *testing.T) {
+		f, err := newCSVFeeder("./testdata/users.csv", "asdf", "", 1)
+		assert.Error(t, err)
+		assert.Nil(t, f)
+
+		f, err = newCSVFeeder("./testdata/users.csv", "", "asdf", 1)
+		assert.Error(t, err)
+		assert.Nil(t, f)
+
+		f, err = newCSVFeeder("./testdata/missing.csv", "", "", 1)
+		assert.Error(t, err)
+		assert.Nil(t, f)
+
+		f, err = newCSVFeeder("./testdata/users.csv", "partition", "", 6)
+		assert.Error(t, err)
+		assert.Nil(t, f)
+	})
+
+	t.Run("no rows", func(t *testing.T) {
+		file, err := ioutil.TempFile("", "ghz_feeder")
+		assert.NoError(t, err)
+		file.WriteString("user_id,name\n")
+		file.Close()
+		defer os.Remove(file.Name())
+
+		f, err := newCSVFeeder(file.Name(), "", "", 1)
+		assert.Error(t, err)
+		assert.Nil(t, f)
+	})
+
+	t.Run("columns", func(t *testing.T) {
+		f, err := newCSVFeeder("./testdata/users.csv", "", "", 1)
+		assert.NoError(t, err)
+
+		row, err := f.next(1)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"user_id": "1", "name": "bob"}, row)
+	})
+
+	t.Run("sequential wrap", func(t *testing.T) {
+		f, err := newCSVFeeder("./testdata/users.csv", "sequential", "wrap", 2)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"1", "2", "3"}, userIDs(f, 1, 3))
+		assert.Equal(t, []string{"4", "5", "1"}, userIDs(f, 2, 3))
+	})
+
+	t.Run("sequential stop", func(t *testing.T) {
+		f, err := newCSVFeeder("./testdata/users.csv", "sequential", "stop", 1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"1", "2", "3", "4", "5", "EOF", "EOF"}, userIDs(f, 1, 7))
+	})
+
+	t.Run("random", func(t *testing.T) {
+		f, err := newCSVFeeder("./testdata/users.csv", "random", "stop", 1)
+		assert.NoError(t, err)
+
+		for _, id := range userIDs(f, 1, 20) {
+			assert.Contains(t, []string{"1", "2", "3", "4", "5"}, id)
+		}
+	})
+
+	t.Run("partition wrap", func(t *testing.T) {
+		f, err := newCSVFeeder("./testdata/users.csv", "partition", "wrap", 2)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"1", "3", "5", "1"}, userIDs(f, 1, 4))
+		assert.Equal(t, []string{"2", "4", "2"}, userIDs(f, 2, 3))
+		assert.Equal(t, []string{"3"}, userIDs(f, 3, 1))
+	})
+
+	t.Run("partition stop", func(t *testing.T) {
+		f, err := newCSVFeeder("./testdata/users.csv", "partition", "stop", 2)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"2", "4", "EOF"}, userIDs(f, 2, 3))
+	})
+
+	t.Run("partition without worker", func(t *testing.T) {
+		f, err := newCSVFeeder("./testdata/users.csv", "partition", "wrap", 2)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"1", "2", "3"}, userIDs(f, 0, 3))
+	})
+}
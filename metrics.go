@@ -0,0 +1,328 @@
+package ghz
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// The buckets of the latency histogram of the metrics in seconds by default
+var defaultMetricsBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// The states of the connection reported in the metrics
+var connectionStates = []connectivity.State{connectivity.Idle, connectivity.Connecting,
+	connectivity.Ready, connectivity.TransientFailure, connectivity.Shutdown}
+
+// metrics are the live metrics of a run, served in the Prometheus text format.
+// The stats handler counts the calls in flight and the connections as they
+// begin and end, and the reporter records the result of every call once its
+// assertions are checked.
+type metrics struct {
+	// the marks of the latency buckets in seconds
+	buckets []float64
+
+	// the number of calls begun and not yet ended, updated atomically
+	inFlight int64
+
+	// the number of connections to the host open, updated atomically
+	conns int64
+
+	mu     sync.Mutex
+	total  uint64
+	calls  map[methodStatus]uint64
+	errors map[methodStatus]uint64
+	lats   map[string]*metricsHistogram
+
+	// the current state of the connection and the times it entered each state
+	state       connectivity.State
+	transitions map[connectivity.State]uint64
+}
+
+type methodStatus struct {
+	method string
+	status string
+}
+
+// metricsHistogram counts the latency of the calls of a method
+// in cumulative buckets
+type metricsHistogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// metricsMethodKey is the key of the method of a call in its context
+type metricsMethodKey struct{}
+
+// newMetrics returns the metrics with the marks of the explicit layout of
+// the buckets of the report for the latency histogram, if it is the one set
+func newMetrics(b *Buckets) *metrics {
+	buckets := defaultMetricsBuckets
+	if b != nil && b.Layout == "explicit" {
+		buckets = make([]float64, len(b.Bounds))
+		for i, mark := range b.Bounds {
+			buckets[i] = mark / 1000
+		}
+	}
+
+	return &metrics{
+		buckets:     buckets,
+		calls:       make(map[methodStatus]uint64),
+		errors:      make(map[methodStatus]uint64),
+		lats:        make(map[string]*metricsHistogram),
+		state:       connectivity.Idle,
+		transitions: make(map[connectivity.State]uint64),
+	}
+}
+
+func (m *metrics) begin() {
+	atomic.AddInt64(&m.inFlight, 1)
+}
+
+func (m *metrics) end() {
+	atomic.AddInt64(&m.inFlight, -1)
+}
+
+func (m *metrics) connBegin() {
+	atomic.AddInt64(&m.conns, 1)
+}
+
+func (m *metrics) connEnd() {
+	atomic.AddInt64(&m.conns, -1)
+}
+
+// record counts the result of a call, which is an error if it failed
+// an assertion or ended with a status other than the expected ones
+func (m *metrics) record(res *callResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := methodStatus{res.method, res.status}
+	m.total++
+	m.calls[key]++
+	if res.err != nil {
+		m.errors[key]++
+	}
+
+	h, ok := m.lats[res.method]
+	if !ok {
+		h = &metricsHistogram{counts: make([]uint64, len(m.buckets))}
+		m.lats[res.method] = h
+	}
+
+	lat := res.duration.Seconds()
+	for i, mark := range m.buckets {
+		if lat <= mark {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += lat
+}
+
+// setState sets the current state of the connection
+func (m *metrics) setState(state connectivity.State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state = state
+	m.transitions[state]++
+}
+
+// watchState tracks the state of the connection as it changes
+// until the context is done
+func (m *metrics) watchState(ctx context.Context, cc *grpc.ClientConn) {
+	state := cc.GetState()
+	m.setState(state)
+	for cc.WaitForStateChange(ctx, state) {
+		state = cc.GetState()
+		m.setState(state)
+	}
+}
+
+// write writes the metrics in the Prometheus text format given how long the
+// run has lasted and the rate it is paced at
+func (m *metrics) write(w io.Writer, elapsed time.Duration, target float64) error {
+	bw := bufio.NewWriter(w)
+
+	m.mu.Lock()
+
+	writeCounts(bw, "ghz_calls_total", "Calls that ended by method and status code.", m.calls)
+	writeCounts(bw, "ghz_call_errors_total", "Calls that failed by method and status code, including the ones failing an assertion.", m.errors)
+
+	methods := make([]string, 0, len(m.lats))
+	for method := range m.lats {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	writeHeader(bw, "ghz_call_duration_seconds", "histogram", "Latency of the calls that ended by method.")
+	for _, method := range methods {
+		h := m.lats[method]
+		label := quoteLabel(method)
+		for i, mark := range m.buckets {
+			fmt.Fprintf(bw, "ghz_call_duration_seconds_bucket{method=%s,le=\"%s\"} %d\n", label, formatFloat(mark), h.counts[i])
+		}
+		fmt.Fprintf(bw, "ghz_call_duration_seconds_bucket{method=%s,le=\"+Inf\"} %d\n", label, h.count)
+		fmt.Fprintf(bw, "ghz_call_duration_seconds_sum{method=%s} %s\n", label, formatFloat(h.sum))
+		fmt.Fprintf(bw, "ghz_call_duration_seconds_count{method=%s} %d\n", label, h.count)
+	}
+
+	writeHeader(bw, "ghz_connection_state", "gauge", "State of the connection to the host, 1 for the current one.")
+	for _, s := range connectionStates {
+		var v int
+		if s == m.state {
+			v = 1
+		}
+		fmt.Fprintf(bw, "ghz_connection_state{state=\"%s\"} %d\n", s, v)
+	}
+
+	writeHeader(bw, "ghz_connection_state_changes_total", "counter", "Times the connection to the host entered each state.")
+	for _, s := range connectionStates {
+		fmt.Fprintf(bw, "ghz_connection_state_changes_total{state=\"%s\"} %d\n", s, m.transitions[s])
+	}
+
+	total := m.total
+	m.mu.Unlock()
+
+	writeHeader(bw, "ghz_connections_open", "gauge", "Connections to the host open.")
+	fmt.Fprintf(bw, "ghz_connections_open %d\n", atomic.LoadInt64(&m.conns))
+
+	writeHeader(bw, "ghz_calls_in_flight", "gauge", "Calls begun and not yet ended.")
+	fmt.Fprintf(bw, "ghz_calls_in_flight %d\n", atomic.LoadInt64(&m.inFlight))
+
+	writeHeader(bw, "ghz_target_rps", "gauge", "Calls per second the run is paced at, 0 if it is not.")
+	fmt.Fprintf(bw, "ghz_target_rps %s\n", formatFloat(target))
+
+	var average float64
+	if elapsed > 0 {
+		average = float64(total) / elapsed.Seconds()
+	}
+	writeHeader(bw, "ghz_average_rps", "gauge", "Calls per second that ended, averaged over the run so far.")
+	fmt.Fprintf(bw, "ghz_average_rps %s\n", formatFloat(average))
+
+	return bw.Flush()
+}
+
+// writeCounts writes a counter by method and status code
+func writeCounts(w io.Writer, name, help string, counts map[methodStatus]uint64) {
+	keys := make([]methodStatus, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	writeHeader(w, name, "counter", help)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s{method=%s,status=%s} %d\n", name, quoteLabel(k.method), quoteLabel(k.status), counts[k])
+	}
+}
+
+func writeHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// quoteLabel returns the label value quoted and escaped
+func quoteLabel(v string) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	v = strings.Replace(v, `"`, `\"`, -1)
+	v = strings.Replace(v, "\n", `\n`, -1)
+	return `"` + v + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// metricsMethod returns the method of a call such as helloworld.Greeter.SayHello
+// given its full name such as /helloworld.Greeter/SayHello
+func metricsMethod(fullName string) string {
+	return strings.Replace(strings.TrimPrefix(fullName, "/"), "/", ".", -1)
+}
+
+// serveMetrics serves the metrics of the run at /metrics on the metrics address
+// and tracks the state of the connection. It returns the function stopping
+// both once the run is over, which keeps serving the final metrics for the
+// metrics linger first.
+func (b *Requester) serveMetrics() (func(), error) {
+	ln, err := net.Listen("tcp", b.config.MetricsAddr)
+	if err != nil {
+		return nil, fmt.Errorf("serving metrics: %v", err)
+	}
+
+	// how long the run lasted once it is over, accessed atomically
+	var over int64
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		elapsed := time.Since(b.start)
+		target := b.targetRate(elapsed)
+		if d := atomic.LoadInt64(&over); d > 0 {
+			elapsed, target = time.Duration(d), 0
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		b.metrics.write(w, elapsed, target)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go b.metrics.watchState(ctx, b.cc)
+
+	srv := &http.Server{Handler: mux}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("serving metrics: %v", err)
+		}
+	}()
+
+	return func() {
+		cancel()
+		atomic.StoreInt64(&over, int64(time.Since(b.start)))
+		if b.config.MetricsLinger > 0 {
+			time.Sleep(b.config.MetricsLinger)
+		}
+		srv.Close()
+	}, nil
+}
+
+// targetRate returns the calls per second the run is paced at given the
+// time elapsed since its start, 0 if the calls are not rate limited
+func (b *Requester) targetRate(elapsed time.Duration) float64 {
+	if len(b.stages) > 0 {
+		for i := range b.stages {
+			s := &b.stages[i]
+			if elapsed < s.Duration {
+				if !s.isRateLimited() {
+					return 0
+				}
+				return s.rate(elapsed)
+			}
+			elapsed -= s.Duration
+		}
+		return 0
+	}
+
+	if b.config.OpenLoop {
+		return float64(b.config.QPS)
+	}
+
+	// every worker is paced at the rate on its own
+	return float64(b.config.QPS * b.config.C)
+}
@@ -0,0 +1,142 @@
+package ghz
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestMetrics_write(t *testing.T) {
+	m := newMetrics(&Buckets{Layout: "explicit", Bounds: []float64{10, 100}})
+	for i := 0; i < 4; i++ {
+		m.begin()
+	}
+	for i := 0; i < 3; i++ {
+		m.end()
+	}
+	m.connBegin()
+	m.connBegin()
+	m.connEnd()
+	m.setState(connectivity.Connecting)
+	m.setState(connectivity.Ready)
+	m.setState(connectivity.TransientFailure)
+	m.setState(connectivity.Ready)
+
+	method := "helloworld.Greeter.SayHello"
+	m.record(&callResult{method: method, status: "OK", duration: 5 * time.Millisecond})
+	m.record(&callResult{method: method, status: "OK", duration: 50 * time.Millisecond, err: errors.New("assertion failed: name")})
+	m.record(&callResult{method: method, status: "Unavailable", duration: 500 * time.Millisecond, err: errors.New("unavailable")})
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.write(&buf, 2*time.Second, 10))
+
+	expected := `# HELP ghz_calls_total Calls that ended by method and status code.
+# TYPE ghz_calls_total counter
+ghz_calls_total{method="helloworld.Greeter.SayHello",status="OK"} 2
+ghz_calls_total{method="helloworld.Greeter.SayHello",status="Unavailable"} 1
+# HELP ghz_call_errors_total Calls that failed by method and status code, including the ones failing an assertion.
+# TYPE ghz_call_errors_total counter
+ghz_call_errors_total{method="helloworld.Greeter.SayHello",status="OK"} 1
+ghz_call_errors_total{method="helloworld.Greeter.SayHello",status="Unavailable"} 1
+# HELP ghz_call_duration_seconds Latency of the calls that ended by method.
+# TYPE ghz_call_duration_seconds histogram
+ghz_call_duration_seconds_bucket{method="helloworld.Greeter.SayHello",le="0.01"} 1
+ghz_call_duration_seconds_bucket{method="helloworld.Greeter.SayHello",le="0.1"} 2
+ghz_call_duration_seconds_bucket{method="helloworld.Greeter.SayHello",le="+Inf"} 3
+ghz_call_duration_seconds_sum{method="helloworld.Greeter.SayHello"} 0.555
+ghz_call_duration_seconds_count{method="helloworld.Greeter.SayHello"} 3
+# HELP ghz_connection_state State of the connection to the host, 1 for the current one.
+# TYPE ghz_connection_state gauge
+ghz_connection_state{state="IDLE"} 0
+ghz_connection_state{state="CONNECTING"} 0
+ghz_connection_state{state="READY"} 1
+ghz_connection_state{state="TRANSIENT_FAILURE"} 0
+ghz_connection_state{state="SHUTDOWN"} 0
+# HELP ghz_connection_state_changes_total Times the connection to the host entered each state.
+# TYPE ghz_connection_state_changes_total counter
+ghz_connection_state_changes_total{state="IDLE"} 0
+ghz_connection_state_changes_total{state="CONNECTING"} 1
+ghz_connection_state_changes_total{state="READY"} 2
+ghz_connection_state_changes_total{state="TRANSIENT_FAILURE"} 1
+ghz_connection_state_changes_total{state="SHUTDOWN"} 0
+# HELP ghz_connections_open Connections to the host open.
+# TYPE ghz_connections_open gauge
+ghz_connections_open 1
+# HELP ghz_calls_in_flight Calls begun and not yet ended.
+# TYPE ghz_calls_in_flight gauge
+ghz_calls_in_flight 1
+# HELP ghz_target_rps Calls per second the run is paced at, 0 if it is not.
+# TYPE ghz_target_rps gauge
+ghz_target_rps 10
+# HELP ghz_average_rps Calls per second that ended, averaged over the run so far.
+# TYPE ghz_average_rps gauge
+ghz_average_rps 1.5
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestMetrics_defaultBuckets(t *testing.T) {
+	m := newMetrics(&Buckets{Layout: "exponential"})
+	assert.Equal(t, defaultMetricsBuckets, m.buckets)
+
+	m = newMetrics(nil)
+	m.record(&callResult{status: "OK", duration: time.Millisecond})
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.write(&buf, 0, 0))
+	assert.True(t, strings.Contains(buf.String(), `ghz_call_duration_seconds_bucket{method="",le="0.001"} 1`))
+	assert.True(t, strings.Contains(buf.String(), `ghz_connection_state{state="IDLE"} 1`))
+	assert.True(t, strings.Contains(buf.String(), "ghz_average_rps 0\n"))
+}
+
+func TestReporter_metrics(t *testing.T) {
+	m := newMetrics(nil)
+	results := make(chan *callResult, 2)
+	r := newReporter(results, &Options{}, time.Now())
+	r.metrics = m
+
+	// the held result of a call failing an assertion is reported with the error,
+	// and the one of a call ending with an expected status without it
+	results <- &callResult{method: "a", status: "OK", err: errors.New("assertion failed: name"), assertions: []string{"name"}}
+	results <- &callResult{method: "a", status: "NotFound"}
+	close(results)
+	go r.Run()
+	<-r.done
+
+	assert.Equal(t, map[methodStatus]uint64{{"a", "OK"}: 1, {"a", "NotFound"}: 1}, m.calls)
+	assert.Equal(t, map[methodStatus]uint64{{"a", "OK"}: 1}, m.errors)
+}
+
+func TestMetrics_labels(t *testing.T) {
+	assert.Equal(t, "helloworld.Greeter.SayHello", metricsMethod("/helloworld.Greeter/SayHello"))
+	assert.Equal(t, `"a\\b\"c\nd"`, quoteLabel("a\\b\"c\nd"))
+}
+
+func TestRequester_targetRate(t *testing.T) {
+	var tests = []struct {
+		name     string
+		opts     Options
+		stages   []Stage
+		elapsed  time.Duration
+		expected float64
+	}{
+		{"workers", Options{QPS: 10, C: 5}, nil, time.Second, 50},
+		{"open loop", Options{QPS: 100, C: 5, OpenLoop: true}, nil, time.Second, 100},
+		{"not paced", Options{C: 5}, nil, time.Second, 0},
+		{"ramp", Options{}, []Stage{{Duration: 10 * time.Second, FromQPS: 0, QPS: 100}}, 5 * time.Second, 50},
+		{"second stage", Options{}, []Stage{{Duration: 10 * time.Second, QPS: 100}, {Duration: 10 * time.Second, C: 10}}, 15 * time.Second, 0},
+		{"over", Options{}, []Stage{{Duration: 10 * time.Second, QPS: 100}}, 15 * time.Second, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Requester{config: &tt.opts, stages: tt.stages}
+			assert.InDelta(t, tt.expected, b.targetRate(tt.elapsed), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The name of the measurement of the summary by default
+const defaultMeasurement = "ghz"
+
+// InfluxOptions configures the InfluxDB line protocol output
+type InfluxOptions struct {
+	// Name of the measurement of the summary, and the prefix of the measurements
+	// of the latency distribution, the status codes and the details. Defaults to ghz.
+	Measurement string
+
+	// Tags of every point, such as the name of the test, the git sha or the environment
+	Tags map[string]string
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	tagEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+	stringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// printInflux prints the summary, the latency distribution, the status codes
+// and the details of the report as InfluxDB line protocol, each call of the
+// details as a point at the time it ended
+func (rp *ReportPrinter) printInflux() {
+	r := rp.Report
+	name := rp.Influx.Measurement
+	if name == "" {
+		name = defaultMeasurement
+	}
+	tags := influxTags(rp.Influx.Tags)
+
+	var buf bytes.Buffer
+	point := func(measurement, tags, fields string, t time.Time) {
+		fmt.Fprintf(&buf, "%s%s %s %d\n", measurementEscaper.Replace(measurement), tags, fields, t.UnixNano())
+	}
+
+	point(name, tags, fmt.Sprintf("count=%di,total=%di,average=%di,fastest=%di,slowest=%di,rps=%s,errors=%di,dropped=%di",
+		r.Count, r.Total, r.Average, r.Fastest, r.Slowest, influxFloat(r.Rps), errorCount(r.ErrorDist), r.Dropped), r.Date)
+
+	for _, ld := range r.LatencyDistribution {
+		point(name+"_latency", influxTag(tags, "percentile", fmt.Sprintf("%v", ld.Percentage)),
+			fmt.Sprintf("latency=%di", ld.Latency), r.Date)
+	}
+
+	codes := make([]string, 0, len(r.StatusLatency))
+	for code := range r.StatusLatency {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		sl := r.StatusLatency[code]
+		point(name+"_status", influxTag(tags, "status", code),
+			fmt.Sprintf("count=%di,average=%di", sl.Count, sl.Average), r.Date)
+	}
+
+	for _, d := range r.Details {
+		fields := fmt.Sprintf("latency=%di", d.Latency)
+		if d.Error != "" {
+			fields += `,error="` + stringEscaper.Replace(d.Error) + `"`
+		}
+		t := d.Timestamp
+		if t.IsZero() {
+			t = r.Date
+		}
+		point(name+"_detail", influxTag(tags, "status", d.Status), fields, t)
+	}
+
+	rp.printf("%s", buf.String())
+}
+
+// influxTags returns the tags sorted by key, each preceded by a comma
+func influxTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var res string
+	for _, k := range keys {
+		res = influxTag(res, k, tags[k])
+	}
+	return res
+}
+
+// influxTag returns the tags with one more, skipping it if the value is empty
+// as the line protocol does not allow it
+func influxTag(tags, key, value string) string {
+	if value == "" {
+		return tags
+	}
+	return tags + "," + tagEscaper.Replace(key) + "=" + tagEscaper.Replace(value)
+}
+
+func influxFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,110 @@
+package printer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz"
+)
+
+func TestInflux_escaping(t *testing.T) {
+	var tests = []struct {
+		name     string
+		escaper  *strings.Replacer
+		in       string
+		expected string
+	}{
+		{"measurement comma", measurementEscaper, "load,test", `load\,test`},
+		{"measurement space", measurementEscaper, "load test", `load\ test`},
+		{"measurement equals", measurementEscaper, "load=test", "load=test"},
+		{"tag comma", tagEscaper, "a,b", `a\,b`},
+		{"tag equals", tagEscaper, "a=b", `a\=b`},
+		{"tag space", tagEscaper, "a b", `a\ b`},
+		{"tag quote", tagEscaper, `a"b`, `a"b`},
+		{"string quote", stringEscaper, `say "hi"`, `say \"hi\"`},
+		{"string backslash", stringEscaper, `C:\tmp`, `C:\\tmp`},
+		{"string newline", stringEscaper, "a\nb", `a\nb`},
+		{"string comma and space", stringEscaper, "a, b", "a, b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.escaper.Replace(tt.in))
+		})
+	}
+}
+
+func TestInflux_tags(t *testing.T) {
+	var tests = []struct {
+		name     string
+		tags     map[string]string
+		expected string
+	}{
+		{"none", nil, ""},
+		{"sorted by key", map[string]string{"sha": "1a2b3c", "env": "staging"}, ",env=staging,sha=1a2b3c"},
+		{"escaped", map[string]string{"test name": "a=b,c"}, `,test\ name=a\=b\,c`},
+		{"empty value skipped", map[string]string{"env": "", "sha": "1a2b3c"}, ",sha=1a2b3c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, influxTags(tt.tags))
+		})
+	}
+}
+
+func TestReportPrinter_influx(t *testing.T) {
+	date := time.Date(2018, 8, 8, 14, 35, 36, 0, time.UTC)
+	report := &ghz.Report{
+		Date:      date,
+		Count:     2,
+		Total:     time.Second,
+		Average:   15 * time.Millisecond,
+		Fastest:   10 * time.Millisecond,
+		Slowest:   20 * time.Millisecond,
+		Rps:       2.5,
+		ErrorDist: map[string]int{"boom": 1},
+		StatusLatency: map[string]ghz.StatusLatency{
+			"Unavailable": {Count: 1, Average: 20 * time.Millisecond},
+			"OK":          {Count: 1, Average: 10 * time.Millisecond},
+		},
+		LatencyDistribution: []ghz.LatencyDistribution{{Percentage: 99.9, Latency: 20 * time.Millisecond}},
+		Details: []ghz.ResultDetail{
+			{Timestamp: date.Add(time.Millisecond), Latency: 10 * time.Millisecond, Status: "OK"},
+			{Latency: 20 * time.Millisecond, Status: "Unavailable", Error: "say \"no\"\nagain"},
+		},
+	}
+
+	var tests = []struct {
+		name     string
+		options  InfluxOptions
+		expected string
+	}{
+		{"defaults", InfluxOptions{}, `ghz count=2i,total=1000000000i,average=15000000i,fastest=10000000i,slowest=20000000i,rps=2.5,errors=1i,dropped=0i 1533738936000000000
+ghz_latency,percentile=99.9 latency=20000000i 1533738936000000000
+ghz_status,status=OK count=1i,average=10000000i 1533738936000000000
+ghz_status,status=Unavailable count=1i,average=20000000i 1533738936000000000
+ghz_detail,status=OK latency=10000000i 1533738936001000000
+ghz_detail,status=Unavailable latency=20000000i,error="say \"no\"\nagain" 1533738936000000000
+`},
+		{"measurement and tags", InfluxOptions{Measurement: "load test", Tags: map[string]string{"env": "ci"}}, `load\ test,env=ci count=2i,total=1000000000i,average=15000000i,fastest=10000000i,slowest=20000000i,rps=2.5,errors=1i,dropped=0i 1533738936000000000
+load\ test_latency,env=ci,percentile=99.9 latency=20000000i 1533738936000000000
+load\ test_status,env=ci,status=OK count=1i,average=10000000i 1533738936000000000
+load\ test_status,env=ci,status=Unavailable count=1i,average=20000000i 1533738936000000000
+load\ test_detail,env=ci,status=OK latency=10000000i 1533738936001000000
+load\ test_detail,env=ci,status=Unavailable latency=20000000i,error="say \"no\"\nagain" 1533738936000000000
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := ReportPrinter{Out: &buf, Report: report, Influx: tt.options}
+			p.Print("influx")
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
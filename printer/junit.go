@@ -0,0 +1,130 @@
+package printer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+
+	"github.com/tab1293/ghz"
+)
+
+// The class names of the test cases of the thresholds,
+// and of the run when there are none
+const (
+	junitClassName    = "ghz.thresholds"
+	junitRunClassName = "ghz.run"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+// printJUnit prints the thresholds of the report as JUnit XML,
+// each threshold a test case failing when it is not met.
+// Without thresholds the run is a single test case failing when any call
+// failed, so that the suite is never empty.
+func (rp *ReportPrinter) printJUnit() {
+	r := rp.Report
+	total := fmt.Sprintf("%.3f", r.Total.Seconds())
+
+	suite := junitTestSuite{
+		Name:      "ghz",
+		Time:      total,
+		Timestamp: r.Date.Format("2006-01-02T15:04:05"),
+		Properties: []junitProperty{
+			{"count", fmt.Sprintf("%d", r.Count)},
+			{"rps", formatSeconds(r.Rps)},
+			{"average", formatMilli(r.Average.Seconds()) + " ms"},
+			{"errors", fmt.Sprintf("%d", errorCount(r.ErrorDist))},
+		},
+	}
+
+	for _, t := range r.Thresholds {
+		tc := junitTestCase{Name: t.Threshold.Expr, ClassName: junitClassName, Time: total}
+		switch {
+		case t.Error != "":
+			tc.Error = &junitMessage{Message: t.Error, Type: "threshold"}
+			suite.Errors++
+		case !t.Pass:
+			tc.Failure = &junitMessage{Message: thresholdMessage(t), Type: "threshold"}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if len(r.Thresholds) == 0 {
+		tc := junitTestCase{Name: "run", ClassName: junitRunClassName, Time: total}
+		errors := errorCount(r.ErrorDist)
+		switch {
+		case r.Count == 0:
+			tc.Error = &junitMessage{Message: "no calls ended", Type: "run"}
+			suite.Errors++
+		case errors > 0:
+			tc.Failure = &junitMessage{Message: fmt.Sprintf("%d of %d calls failed", errors, r.Count), Type: "run"}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+
+	out, err := xml.MarshalIndent(junitTestSuites{
+		Name:     "ghz",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Time:     total,
+		Suites:   []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		log.Println("error:", err.Error())
+		return
+	}
+
+	rp.printf("%s%s\n", xml.Header, out)
+}
+
+// thresholdMessage describes the outcome of a threshold
+// such as p95 is 120.34 ms, expected < 100.00 ms
+func thresholdMessage(t ghz.ThresholdResult) string {
+	if t.Error != "" {
+		return t.Error
+	}
+
+	return fmt.Sprintf("%s is %s, expected %s %s", t.Threshold.Metric,
+		formatThresholdValue(t.Threshold, t.Actual), t.Threshold.Op, formatThresholdValue(t.Threshold, t.Threshold.Value))
+}
@@ -0,0 +1,125 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz"
+)
+
+func TestReportPrinter_junit(t *testing.T) {
+	date := time.Date(2018, 8, 8, 14, 35, 36, 0, time.UTC)
+	report := func(count uint64, errors int, thresholds ...ghz.ThresholdResult) *ghz.Report {
+		r := &ghz.Report{Date: date, Count: count, Total: 1500 * time.Millisecond, Rps: 2,
+			Average: 5 * time.Millisecond, Thresholds: thresholds}
+		if errors > 0 {
+			r.ErrorDist = map[string]int{"boom": errors}
+		}
+		return r
+	}
+
+	var tests = []struct {
+		name     string
+		report   *ghz.Report
+		expected string
+	}{
+		{"thresholds", report(3, 0,
+			ghz.ThresholdResult{Threshold: ghz.Threshold{Metric: "p95", Op: "<", Value: 100, Expr: "p95<100ms"}, Actual: 20, Pass: true},
+			ghz.ThresholdResult{Threshold: ghz.Threshold{Metric: "errors", Op: "<", Value: 1, Expr: "errors<1%"}, Actual: 2.5},
+			ghz.ThresholdResult{Threshold: ghz.Threshold{Metric: "p99.9", Op: "<", Value: 100, Expr: "p99.9<100ms"}, Error: "p99.9 is not in the report"}),
+			`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="ghz" tests="3" failures="1" errors="1" time="1.500">
+  <testsuite name="ghz" tests="3" failures="1" errors="1" time="1.500" timestamp="2018-08-08T14:35:36">
+    <properties>
+      <property name="count" value="3"></property>
+      <property name="rps" value="2.00"></property>
+      <property name="average" value="5.00 ms"></property>
+      <property name="errors" value="0"></property>
+    </properties>
+    <testcase name="p95&lt;100ms" classname="ghz.thresholds" time="1.500"></testcase>
+    <testcase name="errors&lt;1%" classname="ghz.thresholds" time="1.500">
+      <failure message="errors is 2.50 %, expected &lt; 1.00 %" type="threshold"></failure>
+    </testcase>
+    <testcase name="p99.9&lt;100ms" classname="ghz.thresholds" time="1.500">
+      <error message="p99.9 is not in the report" type="threshold"></error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`},
+		{"run passing", report(3, 0), `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="ghz" tests="1" failures="0" errors="0" time="1.500">
+  <testsuite name="ghz" tests="1" failures="0" errors="0" time="1.500" timestamp="2018-08-08T14:35:36">
+    <properties>
+      <property name="count" value="3"></property>
+      <property name="rps" value="2.00"></property>
+      <property name="average" value="5.00 ms"></property>
+      <property name="errors" value="0"></property>
+    </properties>
+    <testcase name="run" classname="ghz.run" time="1.500"></testcase>
+  </testsuite>
+</testsuites>
+`},
+		{"run failing", report(3, 2), `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="ghz" tests="1" failures="1" errors="0" time="1.500">
+  <testsuite name="ghz" tests="1" failures="1" errors="0" time="1.500" timestamp="2018-08-08T14:35:36">
+    <properties>
+      <property name="count" value="3"></property>
+      <property name="rps" value="2.00"></property>
+      <property name="average" value="5.00 ms"></property>
+      <property name="errors" value="2"></property>
+    </properties>
+    <testcase name="run" classname="ghz.run" time="1.500">
+      <failure message="2 of 3 calls failed" type="run"></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`},
+		{"no calls", report(0, 0), `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="ghz" tests="1" failures="0" errors="1" time="1.500">
+  <testsuite name="ghz" tests="1" failures="0" errors="1" time="1.500" timestamp="2018-08-08T14:35:36">
+    <properties>
+      <property name="count" value="0"></property>
+      <property name="rps" value="2.00"></property>
+      <property name="average" value="5.00 ms"></property>
+      <property name="errors" value="0"></property>
+    </properties>
+    <testcase name="run" classname="ghz.run" time="1.500">
+      <error message="no calls ended" type="run"></error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := ReportPrinter{Out: &buf, Report: tt.report}
+			p.Print("junit")
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+
+	t.Run("escaping", func(t *testing.T) {
+		var buf bytes.Buffer
+		p := ReportPrinter{Out: &buf, Report: report(1, 0, ghz.ThresholdResult{
+			Threshold: ghz.Threshold{Metric: "p95", Op: "<", Value: 100, Expr: `p95<100ms&"x"`},
+			Error:     "<bad> & \"quoted\"\n'line'",
+		})}
+		p.Print("junit")
+
+		out := buf.String()
+		assert.Contains(t, out, `name="p95&lt;100ms&amp;&#34;x&#34;"`)
+		assert.Contains(t, out, `message="&lt;bad&gt; &amp; &#34;quoted&#34;&#xA;&#39;line&#39;"`)
+
+		// the escaped values are read back as they were
+		var suites junitTestSuites
+		assert.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+		tc := suites.Suites[0].Cases[0]
+		assert.Equal(t, `p95<100ms&"x"`, tc.Name)
+		assert.Equal(t, "<bad> & \"quoted\"\n'line'", tc.Error.Message)
+	})
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/template"
 	"github.com/tab1293/ghz"
@@ -20,17 +22,43 @@ const (
 type ReportPrinter struct {
 	Out    io.Writer
 	Report *ghz.Report
+
+	// The table of the csv format: details, percentiles, histogram, status
+	// or intervals. Defaults to details.
+	Table string
+
+	// The options of the influx format
+	Influx InfluxOptions
+}
+
+// the templates of the tables of the csv format, which outputs one at a time
+var csvTmpls = map[string]string{
+	"details":     csvTmpl,
+	"percentiles": csvPercentilesTmpl,
+	"histogram":   csvHistogramTmpl,
+	"status":      csvStatusTmpl,
+	"intervals":   csvIntervalsTmpl,
 }
 
 // Print the report using the given format
-// If format is "csv" detailed listing is printer in csv format.
+// If format is "csv" the table set by Table is printed in csv format,
+// the detailed listing by default.
 // Otherwise the summary of results is printed.
 func (rp *ReportPrinter) Print(format string) {
 	switch format {
 	case "", "csv":
 		outputTmpl := defaultTmpl
 		if format == "csv" {
-			outputTmpl = csvTmpl
+			table := rp.Table
+			if table == "" {
+				table = "details"
+			}
+
+			var ok bool
+			if outputTmpl, ok = csvTmpls[table]; !ok {
+				log.Println("error: unknown csv table", table)
+				return
+			}
 		}
 		buf := &bytes.Buffer{}
 		templ := template.Must(template.New("tmpl").Funcs(tmplFuncMap).Parse(outputTmpl))
@@ -39,9 +67,7 @@ func (rp *ReportPrinter) Print(format string) {
 			return
 		}
 
-		rp.printf(buf.String())
-
-		rp.printf("\n")
+		rp.printf("%s\n", buf.String())
 	case "json", "pretty":
 		rep, err := json.Marshal(*rp.Report)
 		if err != nil {
@@ -59,7 +85,7 @@ func (rp *ReportPrinter) Print(format string) {
 			rep = out.Bytes()
 		}
 
-		rp.printf(string(rep))
+		rp.printf("%s", rep)
 	case "html":
 		buf := &bytes.Buffer{}
 		templ := template.Must(template.New("tmpl").Funcs(tmplFuncMap).Parse(htmlTmpl))
@@ -68,7 +94,11 @@ func (rp *ReportPrinter) Print(format string) {
 			return
 		}
 
-		rp.printf(buf.String())
+		rp.printf("%s", buf.String())
+	case "influx":
+		rp.printInflux()
+	case "junit":
+		rp.printJUnit()
 	}
 }
 
@@ -77,12 +107,22 @@ func (rp *ReportPrinter) printf(s string, v ...interface{}) {
 }
 
 var tmplFuncMap = template.FuncMap{
-	"formatMilli":   formatMilli,
-	"formatSeconds": formatSeconds,
-	"histogram":     histogram,
-	"jsonify":       jsonify,
-	"formatMark":    formatMarkMs,
-	"formatPercent": formatPercent,
+	"formatMilli":          formatMilli,
+	"formatSeconds":        formatSeconds,
+	"histogram":            histogram,
+	"jsonify":              jsonify,
+	"formatMark":           formatMarkMs,
+	"formatPercent":        formatPercent,
+	"formatStage":          formatStage,
+	"inc":                  inc,
+	"errorCount":           errorCount,
+	"percentiles":          percentiles,
+	"formatBucket":         formatBucket,
+	"formatFrequency":      formatFrequency,
+	"formatTime":           formatTime,
+	"formatErrors":         formatErrors,
+	"formatPassFail":       formatPassFail,
+	"formatThresholdCheck": formatThresholdCheck,
 }
 
 func jsonify(v interface{}, pretty bool) string {
@@ -132,6 +172,76 @@ func histogram(buckets []ghz.Bucket) string {
 	return res.String()
 }
 
+func formatStage(s ghz.Stage) string {
+	var res string
+	if s.QPS > 0 {
+		res = fmt.Sprintf("%v at %s qps, max %s in flight", s.Duration, formatRange(s.FromQPS, s.QPS), formatRange(s.FromC, s.C))
+	} else {
+		res = fmt.Sprintf("%v with %s workers", s.Duration, formatRange(s.FromC, s.C))
+	}
+	return res
+}
+
+func formatRange(from, to int) string {
+	if from == to {
+		return fmt.Sprintf("%d", to)
+	}
+	return fmt.Sprintf("%d-%d", from, to)
+}
+
+func errorCount(errorDist map[string]int) int {
+	count := 0
+	for _, num := range errorDist {
+		count += num
+	}
+	return count
+}
+
+// percentiles formats the latency distribution on a single line
+func percentiles(lats []ghz.LatencyDistribution) string {
+	res := make([]string, len(lats))
+	for i, ld := range lats {
+		res[i] = fmt.Sprintf("p%v %s ms", ld.Percentage, formatMilli(ld.Latency.Seconds()))
+	}
+	return strings.Join(res, ", ")
+}
+
+func inc(i int) int {
+	return i + 1
+}
+
+func formatBucket(m float64) string {
+	return fmt.Sprintf("%.3f", m*1000)
+}
+
+func formatFrequency(f float64) string {
+	return fmt.Sprintf("%.4f", f)
+}
+
+func formatTime(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// formatErrors formats the number of errors by status code as Unavailable:3 Unknown:1
+func formatErrors(errors map[string]int) string {
+	codes := make([]string, 0, len(errors))
+	for code := range errors {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	res := make([]string, len(codes))
+	for i, code := range codes {
+		res[i] = fmt.Sprintf("%s:%d", code, errors[code])
+	}
+	return strings.Join(res, " ")
+}
+
+// formatThresholdCheck formats a threshold and its outcome for the summary
+func formatThresholdCheck(t ghz.ThresholdResult) string {
+	return t.Threshold.Expr + "\t" + thresholdMessage(t)
+}
+
 func formatMarkMs(m float64) string {
 	return fmt.Sprintf("'%4.3f ms'", m*1000)
 }
@@ -144,21 +254,105 @@ Summary:
   Slowest:	{{ formatMilli .Slowest.Seconds }} ms
   Fastest:	{{ formatMilli .Fastest.Seconds }} ms
   Average:	{{ formatMilli .Average.Seconds }} ms
-  Requests/sec:	{{ formatSeconds .Rps }}
+  Requests/sec:	{{ formatSeconds .Rps }}{{ if gt .Dropped 0 }}
+  Dropped:	{{ .Dropped }}{{ end }}
 
 Response time histogram:
 {{ histogram .Histogram }}
 Latency distribution:{{ range .LatencyDistribution }}
-  {{ .Percentage }}%% in {{ formatMilli .Latency.Seconds }} ms{{ end }}
+  {{ .Percentage }}% in {{ formatMilli .Latency.Seconds }} ms{{ end }}
 Status code distribution:{{ range $code, $num := .StatusCodeDist }}
   [{{ $code }}]	{{ $num }} responses{{ end }}
-{{ if gt (len .ErrorDist) 0 }}Error distribution:{{ range $err, $num := .ErrorDist }}
-  [{{ $num }}]	{{ $err }}{{ end }}{{ end }}
+{{ if gt (len .StatusLatency) 0 }}
+Latency by status code:{{ range $code, $s := .StatusLatency }}
+  [{{ $code }}]	{{ $s.Count }} calls
+    Slowest:	{{ formatMilli $s.Slowest.Seconds }} ms
+    Fastest:	{{ formatMilli $s.Fastest.Seconds }} ms
+    Average:	{{ formatMilli $s.Average.Seconds }} ms
+    Latency distribution:	{{ percentiles $s.LatencyDistribution }}{{ end }}
+{{ end }}{{ if gt (len .ErrorDist) 0 }}Error distribution:{{ range $err, $num := .ErrorDist }}
+  [{{ $num }}]	{{ $err }}{{ end }}
+{{ end }}{{ if gt (len .AssertionDist) 0 }}Failed assertions:{{ range $name, $num := .AssertionDist }}
+  [{{ $num }}]	{{ $name }}{{ end }}
+{{ end }}{{ if .ResponseTime }}
+Response time from intended start:
+  Slowest:	{{ formatMilli .ResponseTime.Slowest.Seconds }} ms
+  Fastest:	{{ formatMilli .ResponseTime.Fastest.Seconds }} ms
+  Average:	{{ formatMilli .ResponseTime.Average.Seconds }} ms
+
+Response time distribution:{{ range .ResponseTime.LatencyDistribution }}
+  {{ .Percentage }}% in {{ formatMilli .Latency.Seconds }} ms{{ end }}
+{{ end }}{{ if .Stages }}
+Stages:{{ range $i, $s := .Stages }}
+  [{{ inc $i }}]	{{ formatStage .Stage }}
+    Count:	{{ .Count }}
+    Total:	{{ formatMilli .Total.Seconds }} ms
+    Slowest:	{{ formatMilli .Slowest.Seconds }} ms
+    Fastest:	{{ formatMilli .Fastest.Seconds }} ms
+    Average:	{{ formatMilli .Average.Seconds }} ms
+    Requests/sec:	{{ formatSeconds .Rps }}
+    Latency distribution:{{ range .LatencyDistribution }}
+      {{ .Percentage }}% in {{ formatMilli .Latency.Seconds }} ms{{ end }}{{ if gt (len .ErrorDist) 0 }}
+    Error distribution:{{ range $err, $num := .ErrorDist }}
+      [{{ $num }}]	{{ $err }}{{ end }}{{ end }}{{ if gt (len .AssertionDist) 0 }}
+    Failed assertions:{{ range $name, $num := .AssertionDist }}
+      [{{ $num }}]	{{ $name }}{{ end }}{{ end }}
+{{ end }}{{ end }}{{ if .Calls }}
+Calls:{{ range .Calls }}
+  [{{ .Name }}]	{{ if ne .Name .Method }}{{ .Method }}, {{ end }}weight {{ .Weight }}
+    Count:	{{ .Count }}
+    Slowest:	{{ formatMilli .Slowest.Seconds }} ms
+    Fastest:	{{ formatMilli .Fastest.Seconds }} ms
+    Average:	{{ formatMilli .Average.Seconds }} ms
+    Requests/sec:	{{ formatSeconds .Rps }}
+    Latency distribution:{{ range .LatencyDistribution }}
+      {{ .Percentage }}% in {{ formatMilli .Latency.Seconds }} ms{{ end }}{{ if gt (len .ErrorDist) 0 }}
+    Error distribution:{{ range $err, $num := .ErrorDist }}
+      [{{ $num }}]	{{ $err }}{{ end }}{{ end }}{{ if gt (len .AssertionDist) 0 }}
+    Failed assertions:{{ range $name, $num := .AssertionDist }}
+      [{{ $num }}]	{{ $name }}{{ end }}{{ end }}
+{{ end }}{{ end }}{{ if .Steps }}
+Steps:{{ range $i, $s := .Steps }}
+  [{{ inc $i }}]	{{ .Name }}{{ if ne .Name .Method }} ({{ .Method }}){{ end }}
+    Count:	{{ .Count }}
+    Slowest:	{{ formatMilli .Slowest.Seconds }} ms
+    Fastest:	{{ formatMilli .Fastest.Seconds }} ms
+    Average:	{{ formatMilli .Average.Seconds }} ms
+    Requests/sec:	{{ formatSeconds .Rps }}
+    Latency distribution:{{ range .LatencyDistribution }}
+      {{ .Percentage }}% in {{ formatMilli .Latency.Seconds }} ms{{ end }}{{ if gt (len .ErrorDist) 0 }}
+    Error distribution:{{ range $err, $num := .ErrorDist }}
+      [{{ $num }}]	{{ $err }}{{ end }}{{ end }}{{ if gt (len .AssertionDist) 0 }}
+    Failed assertions:{{ range $name, $num := .AssertionDist }}
+      [{{ $num }}]	{{ $name }}{{ end }}{{ end }}
+{{ end }}{{ end }}{{ if .Thresholds }}
+Thresholds:{{ range .Thresholds }}
+  [{{ formatPassFail .Pass }}]	{{ formatThresholdCheck . }}{{ end }}{{ end }}
 `
 
 	csvTmpl = `
 duration (ms),status,error{{ range $i, $v := .Details }}
 {{ formatMilli .Latency.Seconds }},{{ .Status }},{{ .Error }}{{ end }}
+`
+
+	csvPercentilesTmpl = `
+percentile,latency (ms){{ range .LatencyDistribution }}
+{{ .Percentage }},{{ formatMilli .Latency.Seconds }}{{ end }}
+`
+
+	csvHistogramTmpl = `
+bucket (ms),count,frequency{{ range .Histogram }}
+{{ formatBucket .Mark }},{{ .Count }},{{ formatFrequency .Frequency }}{{ end }}
+`
+
+	csvStatusTmpl = `
+status,count,average (ms),fastest (ms),slowest (ms){{ range .LatencyDistribution }},p{{ .Percentage }} (ms){{ end }}{{ range $code, $s := .StatusLatency }}
+{{ $code }},{{ $s.Count }},{{ formatMilli $s.Average.Seconds }},{{ formatMilli $s.Fastest.Seconds }},{{ formatMilli $s.Slowest.Seconds }}{{ range $s.LatencyDistribution }},{{ formatMilli .Latency.Seconds }}{{ end }}{{ end }}
+`
+
+	csvIntervalsTmpl = `
+interval start,duration (ms),count,rps,errors,average (ms),fastest (ms),slowest (ms){{ range .LatencyDistribution }},p{{ .Percentage }} (ms){{ end }}{{ range .Intervals }}
+{{ formatTime .Start }},{{ formatMilli .Duration.Seconds }},{{ .Count }},{{ formatSeconds .Rps }},{{ formatErrors .Errors }},{{ formatMilli .Average.Seconds }},{{ formatMilli .Fastest.Seconds }},{{ formatMilli .Slowest.Seconds }}{{ range .LatencyDistribution }},{{ formatMilli .Latency.Seconds }}{{ end }}{{ end }}
 `
 
 	htmlTmpl = `
@@ -272,6 +466,12 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 									<th>Requests / sec</th>
 									<td>{{ formatSeconds .Rps }}</td>
 								</tr>
+								{{ if gt .Dropped 0 }}
+								<tr>
+									<th>Dropped</th>
+									<td>{{ .Dropped }}</td>
+								</tr>
+								{{ end }}
 							</tbody>
 						</table>
 					</div>
@@ -313,7 +513,7 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 					<thead>
 						<tr>
 							{{ range .LatencyDistribution }}
-								<th>{{ .Percentage }} %%</th>
+								<th>{{ .Percentage }} %</th>
 							{{ end }}
 						</tr>
 					</thead>
@@ -328,6 +528,179 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 			</div>
 		</div>
 
+		{{ if .ResponseTime }}
+		<br />
+		<div class="container">
+			<div class="content">
+				<a name="responsetime">
+					<h3>Response time from intended start</h3>
+				</a>
+				<table class="table is-fullwidth">
+					<thead>
+						<tr>
+							<th>Fastest</th>
+							<th>Average</th>
+							<th>Slowest</th>
+							{{ range .ResponseTime.LatencyDistribution }}
+								<th>{{ .Percentage }} %</th>
+							{{ end }}
+						</tr>
+					</thead>
+					<tbody>
+						<tr>
+							<td>{{ formatMilli .ResponseTime.Fastest.Seconds }} ms</td>
+							<td>{{ formatMilli .ResponseTime.Average.Seconds }} ms</td>
+							<td>{{ formatMilli .ResponseTime.Slowest.Seconds }} ms</td>
+							{{ range .ResponseTime.LatencyDistribution }}
+								<td>{{ formatMilli .Latency.Seconds }} ms</td>
+							{{ end }}
+						</tr>
+					</tbody>
+				</table>
+			</div>
+		</div>
+		{{ end }}
+
+		{{ if .Intervals }}
+		<br />
+		<div class="container">
+			<div class="content">
+				<a name="timeline">
+					<h3>Timeline</h3>
+				</a>
+				<h5>Latency (ms)</h5>
+				<p>
+					<div class="js-timeline-latency-container"></div>
+				</p>
+				<h5>Requests and errors / sec</h5>
+				<p>
+					<div class="js-timeline-rps-container"></div>
+				</p>
+			</div>
+		</div>
+		{{ end }}
+
+		{{ if .Stages }}
+		<br />
+		<div class="container">
+			<div class="content">
+				<a name="stages">
+					<h3>Stages</h3>
+				</a>
+				<table class="table is-fullwidth is-hoverable">
+					<thead>
+						<tr>
+							<th>Stage</th>
+							<th>Profile</th>
+							<th>Count</th>
+							<th>Requests / sec</th>
+							<th>Fastest</th>
+							<th>Average</th>
+							<th>Slowest</th>
+							<th>Errors</th>
+						</tr>
+					</thead>
+					<tbody>
+						{{ range $i, $s := .Stages }}
+							<tr>
+								<td>{{ inc $i }}</td>
+								<td>{{ formatStage .Stage }}</td>
+								<td>{{ .Count }}</td>
+								<td>{{ formatSeconds .Rps }}</td>
+								<td>{{ formatMilli .Fastest.Seconds }} ms</td>
+								<td>{{ formatMilli .Average.Seconds }} ms</td>
+								<td>{{ formatMilli .Slowest.Seconds }} ms</td>
+								<td>{{ errorCount .ErrorDist }}</td>
+							</tr>
+						{{ end }}
+					</tbody>
+				</table>
+			</div>
+		</div>
+		{{ end }}
+
+		{{ if .Calls }}
+		<br />
+		<div class="container">
+			<div class="content">
+				<a name="calls">
+					<h3>Calls</h3>
+				</a>
+				<table class="table is-fullwidth is-hoverable">
+					<thead>
+						<tr>
+							<th>Name</th>
+							<th>Call</th>
+							<th>Weight</th>
+							<th>Count</th>
+							<th>Requests / sec</th>
+							<th>Fastest</th>
+							<th>Average</th>
+							<th>Slowest</th>
+							<th>Errors</th>
+						</tr>
+					</thead>
+					<tbody>
+						{{ range .Calls }}
+							<tr>
+								<td>{{ .Name }}</td>
+								<td>{{ .Method }}</td>
+								<td>{{ .Weight }}</td>
+								<td>{{ .Count }}</td>
+								<td>{{ formatSeconds .Rps }}</td>
+								<td>{{ formatMilli .Fastest.Seconds }} ms</td>
+								<td>{{ formatMilli .Average.Seconds }} ms</td>
+								<td>{{ formatMilli .Slowest.Seconds }} ms</td>
+								<td>{{ errorCount .ErrorDist }}</td>
+							</tr>
+						{{ end }}
+					</tbody>
+				</table>
+			</div>
+		</div>
+		{{ end }}
+
+		{{ if .Steps }}
+		<br />
+		<div class="container">
+			<div class="content">
+				<a name="steps">
+					<h3>Steps</h3>
+				</a>
+				<table class="table is-fullwidth is-hoverable">
+					<thead>
+						<tr>
+							<th>Step</th>
+							<th>Name</th>
+							<th>Call</th>
+							<th>Count</th>
+							<th>Requests / sec</th>
+							<th>Fastest</th>
+							<th>Average</th>
+							<th>Slowest</th>
+							<th>Errors</th>
+						</tr>
+					</thead>
+					<tbody>
+						{{ range $i, $s := .Steps }}
+							<tr>
+								<td>{{ inc $i }}</td>
+								<td>{{ .Name }}</td>
+								<td>{{ .Method }}</td>
+								<td>{{ .Count }}</td>
+								<td>{{ formatSeconds .Rps }}</td>
+								<td>{{ formatMilli .Fastest.Seconds }} ms</td>
+								<td>{{ formatMilli .Average.Seconds }} ms</td>
+								<td>{{ formatMilli .Slowest.Seconds }} ms</td>
+								<td>{{ errorCount .ErrorDist }}</td>
+							</tr>
+						{{ end }}
+					</tbody>
+				</table>
+			</div>
+		</div>
+		{{ end }}
+
 		<br />
 		<div class="container">
 			<div class="columns">
@@ -341,7 +714,7 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 								<tr>
 									<th>Status</th>
 									<th>Count</th>
-									<th>%% of Total</th>
+									<th>% of Total</th>
 								</tr>
 							</thead>
 							<tbody>
@@ -349,7 +722,7 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 									<tr>
 									  <td>{{ $code }}</td>
 										<td>{{ $num }}</td>
-										<td>{{ formatPercent $num $.Count }} %%</td>
+										<td>{{ formatPercent $num $.Count }} %</td>
 									</tr>
 									{{ end }}
 								</tbody>
@@ -359,6 +732,47 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 				</div>
 			</div>
 
+			{{ if gt (len .StatusLatency) 0 }}
+
+				<br />
+				<div class="container">
+					<div class="content">
+						<a name="statuslatency">
+							<h3>Latency by status code</h3>
+						</a>
+						<table class="table is-fullwidth is-hoverable">
+							<thead>
+								<tr>
+									<th>Status</th>
+									<th>Count</th>
+									<th>Fastest</th>
+									<th>Average</th>
+									<th>Slowest</th>
+									{{ range .LatencyDistribution }}
+									<th>{{ .Percentage }} %</th>
+									{{ end }}
+								</tr>
+							</thead>
+							<tbody>
+								{{ range $code, $s := .StatusLatency }}
+									<tr>
+										<td>{{ $code }}</td>
+										<td>{{ $s.Count }}</td>
+										<td>{{ formatMilli $s.Fastest.Seconds }} ms</td>
+										<td>{{ formatMilli $s.Average.Seconds }} ms</td>
+										<td>{{ formatMilli $s.Slowest.Seconds }} ms</td>
+										{{ range $s.LatencyDistribution }}
+										<td>{{ formatMilli .Latency.Seconds }} ms</td>
+										{{ end }}
+									</tr>
+								{{ end }}
+							</tbody>
+						</table>
+					</div>
+				</div>
+
+			{{ end }}
+
 			{{ if gt (len .ErrorDist) 0 }}
 
 				<br />
@@ -374,7 +788,7 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 										<tr>
 											<th>Error</th>
 											<th>Count</th>
-											<th>%% of Total</th>
+											<th>% of Total</th>
 										</tr>
 									</thead>
 									<tbody>
@@ -382,7 +796,42 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 											<tr>
 												<td>{{ $err }}</td>
 												<td>{{ $num }}</td>
-												<td>{{ formatPercent $num $.Count }} %%</td>
+												<td>{{ formatPercent $num $.Count }} %</td>
+											</tr>
+											{{ end }}
+										</tbody>
+									</table>
+								</div>
+							</div>
+						</div>
+					</div>
+
+			{{ end }}
+
+			{{ if gt (len .AssertionDist) 0 }}
+
+				<br />
+				<div class="container">
+					<div class="columns">
+						<div class="column is-narrow">
+							<div class="content">
+								<a name="assertions">
+									<h3>Failed Assertions</h3>
+								</a>
+								<table class="table is-hoverable">
+									<thead>
+										<tr>
+											<th>Assertion</th>
+											<th>Count</th>
+											<th>% of Total</th>
+										</tr>
+									</thead>
+									<tbody>
+										{{ range $name, $num := .AssertionDist }}
+											<tr>
+												<td>{{ $name }}</td>
+												<td>{{ $num }}</td>
+												<td>{{ formatPercent $num $.Count }} %</td>
 											</tr>
 											{{ end }}
 										</tbody>
@@ -447,7 +896,7 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 		tooltip.numberFormat('')
 		tooltip.valueFormatter(function(v) {
 			var percent = v / count * 100;
-			return v + ' ' + '(' + Number.parseFloat(percent).toFixed(1) + ' %%)';
+			return v + ' ' + '(' + Number.parseFloat(percent).toFixed(1) + ' %)';
 		})
 
 		if (containerWidth) {
@@ -490,6 +939,77 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 		}
 	}
 
+	const intervals = {{ jsonify .Intervals false }} || [];
+
+	// timelineTopics returns the line chart data of the values of the intervals
+	function timelineTopics(topics) {
+		return {
+			dataByTopic: topics.map(function(t, i) {
+				return {
+					topicName: t.name,
+					topic: i + 1,
+					dates: intervals.map(function(iv) {
+						return { date: iv.start, value: t.value(iv) };
+					})
+				};
+			})
+		};
+	}
+
+	function createTimelineChart(selector, data) {
+		let lineChart = britecharts.line(),
+			container = d3.select(selector),
+			containerWidth = container.node() ? container.node().getBoundingClientRect().width : false;
+
+		if (containerWidth) {
+			lineChart
+				.isAnimated(true)
+				.grid('horizontal')
+				.margin({
+					left: 60,
+					right: 20,
+					top: 20,
+					bottom: 40
+				})
+				.width(containerWidth)
+				.height(300);
+
+			container.datum(data).call(lineChart);
+		}
+	}
+
+	function createTimelineCharts() {
+		if (intervals.length == 0) {
+			return;
+		}
+
+		const toMs = function(ns) { return ns / 1000000; };
+
+		let latency = [
+			{ name: 'average', value: function(iv) { return toMs(iv.average); } }
+		];
+		(intervals[0].latencyDistribution || []).forEach(function(ld, i) {
+			latency.push({
+				name: 'p' + ld.percentage,
+				value: function(iv) { return toMs(iv.latencyDistribution[i].latency); }
+			});
+		});
+
+		let errorRate = function(iv) {
+			let res = 0;
+			for (let code in iv.errors || {}) {
+				res += iv.errors[code];
+			}
+			return iv.duration > 0 ? res / (iv.duration / 1000000000) : 0;
+		};
+
+		createTimelineChart('.js-timeline-latency-container', timelineTopics(latency));
+		createTimelineChart('.js-timeline-rps-container', timelineTopics([
+			{ name: 'rps', value: function(iv) { return iv.rps; } },
+			{ name: 'errors / sec', value: errorRate }
+		]));
+	}
+
 	function setJSONDownloadLink () {
 		var filename = "data.json";
 		var btn = document.getElementById('dlJSON');
@@ -512,6 +1032,8 @@ duration (ms),status,error{{ range $i, $v := .Details }}
 
 	createHorizontalBarChart();
 
+	createTimelineCharts();
+
 	setJSONDownloadLink();
 
 	setCSVDownloadLink();
@@ -0,0 +1,152 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz"
+)
+
+// newTestReport returns a report of three calls, one of which failed
+// with an error holding a percent sign like the error rate threshold
+func newTestReport() *ghz.Report {
+	date := time.Date(2018, 8, 8, 14, 35, 36, 0, time.UTC)
+	lats := func(p50, p99 time.Duration) []ghz.LatencyDistribution {
+		return []ghz.LatencyDistribution{{Percentage: 50, Latency: p50}, {Percentage: 99, Latency: p99}}
+	}
+
+	return &ghz.Report{
+		Date:    date,
+		Count:   3,
+		Total:   time.Second,
+		Average: 20 * time.Millisecond,
+		Fastest: 10 * time.Millisecond,
+		Slowest: 30 * time.Millisecond,
+		Rps:     3,
+		ErrorDist: map[string]int{
+			"rpc error: code = Unavailable desc = 100% down": 1,
+		},
+		StatusCodeDist: map[string]int{"OK": 2, "Unavailable": 1},
+		StatusLatency: map[string]ghz.StatusLatency{
+			"OK":          {Count: 2, Average: 15 * time.Millisecond, Fastest: 10 * time.Millisecond, Slowest: 20 * time.Millisecond, LatencyDistribution: lats(10*time.Millisecond, 20*time.Millisecond)},
+			"Unavailable": {Count: 1, Average: 30 * time.Millisecond, Fastest: 30 * time.Millisecond, Slowest: 30 * time.Millisecond, LatencyDistribution: lats(30*time.Millisecond, 30*time.Millisecond)},
+		},
+		Thresholds: []ghz.ThresholdResult{
+			{Threshold: ghz.Threshold{Metric: "p99", Op: "<", Value: 100, Expr: "p99<100ms"}, Actual: 30, Pass: true},
+			{Threshold: ghz.Threshold{Metric: "errors", Op: "<", Value: 1, Expr: "errors<1%"}, Actual: 100.0 / 3},
+		},
+		Intervals: []ghz.Interval{
+			{Start: date, Duration: time.Second, Count: 3, Rps: 3, Errors: map[string]int{"Unavailable": 1},
+				Average: 20 * time.Millisecond, Fastest: 10 * time.Millisecond, Slowest: 30 * time.Millisecond, LatencyDistribution: lats(20*time.Millisecond, 30*time.Millisecond)},
+		},
+		LatencyDistribution: lats(20*time.Millisecond, 30*time.Millisecond),
+		Histogram: []ghz.Bucket{
+			{Mark: 0.01, Count: 1, Frequency: 1.0 / 3},
+			{Mark: 0.03, Count: 2, Frequency: 2.0 / 3},
+		},
+		Details: []ghz.ResultDetail{
+			{Timestamp: date.Add(10 * time.Millisecond), Latency: 10 * time.Millisecond, Status: "OK"},
+			{Timestamp: date.Add(20 * time.Millisecond), Latency: 20 * time.Millisecond, Status: "OK"},
+			{Timestamp: date.Add(30 * time.Millisecond), Latency: 30 * time.Millisecond, Status: "Unavailable", Error: "rpc error: code = Unavailable desc = 100% down"},
+		},
+	}
+}
+
+func TestReportPrinter_Print(t *testing.T) {
+	print := func(format string) string {
+		var buf bytes.Buffer
+		p := ReportPrinter{Out: &buf, Report: newTestReport()}
+		p.Print(format)
+		return buf.String()
+	}
+
+	t.Run("json", func(t *testing.T) {
+		for _, format := range []string{"json", "pretty"} {
+			out := print(format)
+			assert.NotContains(t, out, "%!")
+
+			var rep map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(out), &rep), format)
+			assert.Equal(t, "errors<1%", rep["thresholds"].([]interface{})[1].(map[string]interface{})["threshold"].(map[string]interface{})["expr"])
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		out := print("html")
+		assert.NotContains(t, out, "%!")
+		assert.Contains(t, out, "rpc error: code = Unavailable desc = 100% down")
+		assert.Contains(t, out, "<th>99 %</th>")
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		out := print("")
+		assert.NotContains(t, out, "%!")
+		assert.Contains(t, out, "99% in 30.00 ms")
+		assert.Contains(t, out, "[pass]\tp99<100ms\tp99 is 30.00 ms, expected < 100.00 ms")
+		assert.Contains(t, out, "[fail]\terrors<1%\terrors is 33.33 %, expected < 1.00 %")
+		assert.True(t, strings.HasSuffix(out, "\n"))
+		assert.Contains(t, out, "Latency by status code:\n  [OK]\t2 calls")
+	})
+
+	t.Run("no latency by status code", func(t *testing.T) {
+		for _, format := range []string{"", "html"} {
+			var buf bytes.Buffer
+			r := newTestReport()
+			r.StatusLatency = nil
+			p := ReportPrinter{Out: &buf, Report: r}
+			p.Print(format)
+
+			assert.NotContains(t, buf.String(), "Latency by status code", format)
+		}
+	})
+}
+
+func TestReportPrinter_csv(t *testing.T) {
+	var tests = []struct {
+		table    string
+		expected string
+	}{
+		{"", `
+duration (ms),status,error
+10.00,OK,
+20.00,OK,
+30.00,Unavailable,rpc error: code = Unavailable desc = 100% down
+`},
+		{"percentiles", `
+percentile,latency (ms)
+50,20.00
+99,30.00
+`},
+		{"histogram", `
+bucket (ms),count,frequency
+10.000,1,0.3333
+30.000,2,0.6667
+`},
+		{"status", `
+status,count,average (ms),fastest (ms),slowest (ms),p50 (ms),p99 (ms)
+OK,2,15.00,10.00,20.00,10.00,20.00
+Unavailable,1,30.00,30.00,30.00,30.00,30.00
+`},
+		{"intervals", `
+interval start,duration (ms),count,rps,errors,average (ms),fastest (ms),slowest (ms),p50 (ms),p99 (ms)
+2018-08-08T14:35:36.000Z,1000.00,3,3.00,Unavailable:1,20.00,10.00,30.00,20.00,30.00
+`},
+		{"unknown", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := ReportPrinter{Out: &buf, Report: newTestReport(), Table: tt.table}
+			p.Print("csv")
+			if tt.expected != "" {
+				tt.expected += "\n"
+			}
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
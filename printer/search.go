@@ -0,0 +1,108 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/alecthomas/template"
+	"github.com/tab1293/ghz"
+)
+
+// SearchPrinter is used for printing the saturation search report
+type SearchPrinter struct {
+	Out    io.Writer
+	Report *ghz.SearchReport
+}
+
+// Print the search report using the given format
+// If format is "json" or "pretty" the report is printed as JSON.
+// Otherwise a table of the steps is printed.
+func (sp *SearchPrinter) Print(format string) {
+	switch format {
+	case "json", "pretty":
+		rep, err := json.Marshal(*sp.Report)
+		if err != nil {
+			log.Println("error:", err.Error())
+			return
+		}
+
+		if format == "pretty" {
+			var out bytes.Buffer
+			err = json.Indent(&out, rep, "", "  ")
+			if err != nil {
+				log.Println("error:", err.Error())
+				return
+			}
+			rep = out.Bytes()
+		}
+
+		fmt.Fprint(sp.Out, string(rep))
+	default:
+		buf := &bytes.Buffer{}
+		templ := template.Must(template.New("tmpl").Funcs(searchTmplFuncMap).Parse(searchTmpl))
+		if err := templ.Execute(buf, *sp.Report); err != nil {
+			log.Println("error:", err.Error())
+			return
+		}
+
+		fmt.Fprint(sp.Out, buf.String())
+	}
+}
+
+var searchTmplFuncMap = template.FuncMap{
+	"formatSeconds":   formatSeconds,
+	"formatSLO":       formatSLO,
+	"formatThreshold": formatThresholdResult,
+	"formatPassFail":  formatPassFail,
+}
+
+func formatSLO(thresholds []ghz.Threshold) string {
+	exprs := make([]string, len(thresholds))
+	for i, t := range thresholds {
+		exprs[i] = t.Expr
+	}
+	return strings.Join(exprs, ", ")
+}
+
+func formatThresholdValue(t ghz.Threshold, v float64) string {
+	switch {
+	case t.IsLatency():
+		return fmt.Sprintf("%4.2f ms", v)
+	case t.Metric == "errors":
+		return fmt.Sprintf("%.2f %%", v)
+	}
+	return fmt.Sprintf("%4.2f", v)
+}
+
+func formatThresholdResult(r ghz.ThresholdResult) string {
+	if r.Error != "" {
+		return r.Error
+	}
+	return formatThresholdValue(r.Threshold, r.Actual)
+}
+
+func formatPassFail(pass bool) string {
+	if pass {
+		return "pass"
+	}
+	return "fail"
+}
+
+var searchTmpl = `
+Search:
+  By:	{{ .Search.By }}
+  Range:	{{ .Search.Min }} - {{ .Search.Max }}
+  Step:	{{ .Search.StepDuration }}
+  SLO:	{{ formatSLO .Search.SLO }}{{ if eq .Search.By "qps" }}
+  In flight:	not capped, so that a saturated server shows in the latency and errors of a step rather than in calls dropped by the client{{ end }}
+
+Steps:
+  Load	Count	Requests/sec	Errors{{ range .Search.SLO }}	{{ .Metric }}{{ end }}	Result{{ range .Steps }}
+  {{ .Load }}	{{ .Count }}	{{ formatSeconds .Rps }}	{{ printf "%.2f %%" .ErrorRate }}{{ range .Results }}	{{ formatThreshold . }}{{ end }}	{{ formatPassFail .Pass }}{{ end }}
+
+{{ if .Found }}Max {{ .Search.By }} within SLO:	{{ .Max }}{{ else }}No load within the range met the SLO{{ end }}
+`
@@ -0,0 +1,159 @@
+package ghz
+
+import (
+	"time"
+)
+
+// How often the progress of a run is reported
+const progressInterval = time.Second
+
+// The percentiles of the latency in the progress of a run
+var progressPercentiles = []float64{50, 95, 99}
+
+// The number of the latest seconds of latency kept in the progress of a run
+const progressHistory = 60
+
+// The number of the latest snapshots the current rps and latency cover
+const progressWindows = 10
+
+// Progress is a snapshot of the results of a run in progress, reported
+// every second. The current rps and latency cover the calls that ended
+// within the window, the last 10 seconds once the run has lasted as long.
+type Progress struct {
+	Elapsed time.Duration `json:"elapsed"`
+
+	// The number of calls that ended
+	Count uint64 `json:"count"`
+
+	// The number of calls to make, or how long the run lasts
+	// when it is not limited by the number of calls
+	N        int           `json:"n,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// How long before the snapshot the current rps and latency go back
+	Window time.Duration `json:"window"`
+
+	Rps        float64 `json:"rps"`
+	AverageRps float64 `json:"averageRps"`
+
+	// The median, 95th and 99th percentiles of the current latency
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+
+	// The number of calls that failed by status code
+	Errors map[string]int `json:"errors,omitempty"`
+
+	// The median latency of every second, the current one last
+	History []time.Duration `json:"history"`
+}
+
+// Done returns the share of the run done, from 0 to 1
+func (p *Progress) Done() float64 {
+	var done float64
+	if p.Duration > 0 {
+		done = p.Elapsed.Seconds() / p.Duration.Seconds()
+	} else if p.N > 0 {
+		done = float64(p.Count) / float64(p.N)
+	}
+
+	if done > 1 {
+		done = 1
+	}
+	return done
+}
+
+// progress returns a snapshot of the results given how long the run has
+// lasted. The calls since the previous snapshot join the ring of the latest
+// windows, which are merged for the current rps and latency.
+func (r *Reporter) progress(elapsed time.Duration) Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the median of the last second alone makes the history
+	median := r.window.latencies(progressPercentiles[:1])[0].Latency
+
+	r.windows = append(r.windows, progressWindow{stats: r.window, start: r.windowStart})
+	if len(r.windows) > progressWindows {
+		// the stats of the oldest window are reused for the next one
+		r.window = r.windows[0].stats
+		r.window.reset()
+		r.windows = r.windows[1:]
+	} else {
+		r.window = newLatencyStats(r.precision)
+	}
+	r.windowStart = elapsed
+
+	current := newLatencyStats(r.precision)
+	for _, w := range r.windows {
+		current.merge(w.stats)
+	}
+
+	p := Progress{
+		Elapsed:             elapsed,
+		Count:               r.totalCount,
+		Window:              elapsed - r.windows[0].start,
+		LatencyDistribution: current.latencies(progressPercentiles),
+	}
+
+	if p.Window > 0 {
+		p.Rps = float64(current.count) / p.Window.Seconds()
+	}
+
+	if elapsed > 0 {
+		p.AverageRps = float64(r.totalCount) / elapsed.Seconds()
+	}
+
+	if len(r.statusErrors) > 0 {
+		p.Errors = make(map[string]int, len(r.statusErrors))
+		for code, n := range r.statusErrors {
+			p.Errors[code] = n
+		}
+	}
+
+	r.history = append(r.history, median)
+	if len(r.history) > progressHistory {
+		r.history = r.history[len(r.history)-progressHistory:]
+	}
+	p.History = append([]time.Duration(nil), r.history...)
+
+	return p
+}
+
+// progressWindow holds the latency of the calls that ended between two
+// snapshots of the progress, from the start given as the time since the
+// start of the run
+type progressWindow struct {
+	stats *latencyStats
+	start time.Duration
+}
+
+// reportProgress reports the progress of the run every second until done is closed
+func (b *Requester) reportProgress(done chan struct{}) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			p := b.reporter.progress(time.Since(b.start))
+			p.N = b.config.N
+			p.Duration = b.runDuration()
+			b.config.OnProgress(p)
+		}
+	}
+}
+
+// runDuration returns how long the run lasts if it is
+// limited by time rather than the number of calls
+func (b *Requester) runDuration() time.Duration {
+	if b.config.Z > 0 {
+		return b.config.Z
+	}
+
+	var res time.Duration
+	for _, s := range b.stages {
+		res += s.Duration
+	}
+	return res
+}
@@ -0,0 +1,86 @@
+package ghz
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress_Done(t *testing.T) {
+	var tests = []struct {
+		name     string
+		progress Progress
+		expected float64
+	}{
+		{"count", Progress{Count: 250, N: 1000}, 0.25},
+		{"duration", Progress{Elapsed: 3 * time.Second, Count: 250, N: 1000, Duration: 10 * time.Second}, 0.3},
+		{"over", Progress{Count: 12, N: 10}, 1},
+		{"unknown", Progress{Count: 12}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, tt.progress.Done(), 1e-9)
+		})
+	}
+}
+
+func TestReporter_progress(t *testing.T) {
+	r := newReporter(nil, &Options{N: 10, Precision: 5}, time.Now())
+
+	r.add(&callResult{status: "OK", duration: 10 * time.Millisecond})
+	r.add(&callResult{status: "OK", duration: 20 * time.Millisecond})
+	r.add(&callResult{err: errors.New("unavailable"), status: "Unavailable", duration: 30 * time.Millisecond})
+	r.add(&callResult{status: "OK", duration: 40 * time.Millisecond})
+
+	p := r.progress(2 * time.Second)
+	assert.Equal(t, 2*time.Second, p.Elapsed)
+	assert.Equal(t, uint64(4), p.Count)
+	assert.Equal(t, 2.0, p.Rps)
+	assert.Equal(t, 2.0, p.AverageRps)
+	assert.Equal(t, []LatencyDistribution{
+		{Percentage: 50, Latency: 20 * time.Millisecond},
+		{Percentage: 95, Latency: 40 * time.Millisecond},
+		{Percentage: 99, Latency: 40 * time.Millisecond},
+	}, p.LatencyDistribution)
+	assert.Equal(t, map[string]int{"Unavailable": 1}, p.Errors)
+	assert.Equal(t, []time.Duration{20 * time.Millisecond}, p.History)
+
+	assert.Equal(t, 2*time.Second, p.Window)
+
+	// the current rps and latency cover the calls of the latest snapshots
+	// while the history is the median of every snapshot on its own
+	r.add(&callResult{status: "OK", duration: 5 * time.Millisecond})
+
+	p = r.progress(2500 * time.Millisecond)
+	assert.Equal(t, uint64(5), p.Count)
+	assert.Equal(t, 2500*time.Millisecond, p.Window)
+	assert.Equal(t, 2.0, p.Rps)
+	assert.Equal(t, 2.0, p.AverageRps)
+	assert.Equal(t, 20*time.Millisecond, p.LatencyDistribution[0].Latency)
+	assert.Equal(t, []time.Duration{20 * time.Millisecond, 5 * time.Millisecond}, p.History)
+
+	// no call ended since the last snapshot
+	p = r.progress(5 * time.Second)
+	assert.Equal(t, 1.0, p.Rps)
+	assert.Equal(t, 20*time.Millisecond, p.LatencyDistribution[0].Latency)
+	assert.Equal(t, time.Duration(0), p.History[2])
+	assert.Len(t, p.History, 3)
+
+	// the calls of the snapshots older than the window are left out
+	for i := 0; i < progressWindows-2; i++ {
+		p = r.progress(time.Duration(6+i) * time.Second)
+	}
+	assert.Equal(t, 13*time.Second, p.Elapsed)
+	assert.Equal(t, 11*time.Second, p.Window)
+	assert.InDelta(t, 1.0/11, p.Rps, 1e-9)
+	assert.Equal(t, 5*time.Millisecond, p.LatencyDistribution[2].Latency)
+
+	for i := 0; i < progressHistory; i++ {
+		p = r.progress(time.Duration(14+i) * time.Second)
+	}
+	assert.Equal(t, 0.0, p.Rps)
+	assert.Len(t, p.History, progressHistory)
+}
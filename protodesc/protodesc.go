@@ -3,40 +3,171 @@ package protodesc
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/grpcreflect"
 )
 
 // GetMethodDescFromProto gets method descritor for the given call symbol from proto file given my path proto
 // imports is used for import paths in parsing the proto file
 func GetMethodDescFromProto(call, proto string, imports []string) (*desc.MethodDescriptor, error) {
-	p := &protoparse.Parser{ImportPaths: imports}
-
-	filename := proto
-	if filepath.IsAbs(filename) {
-		filename = filepath.Base(proto)
-	}
+	return GetMethodDescFromProtos(call, []string{proto}, imports)
+}
 
-	fds, err := p.ParseFiles(filename)
+// GetMethodDescFromProtos gets method descritor for the given call symbol from the proto files.
+// Each of protos can be a file, a glob pattern or a directory, in which case all the proto files
+// in the directory and its subdirectories are used and the directory is added to the import paths.
+// The symbol is looked up in all the files and their dependencies.
+func GetMethodDescFromProtos(call string, protos []string, imports []string) (*desc.MethodDescriptor, error) {
+	files, err := ParseProtos(protos, imports)
 	if err != nil {
 		return nil, err
 	}
 
-	fileDesc := fds[0]
+	return getMethodDesc(call, files)
+}
+
+// ParseProtos parses the proto files, given as files, glob patterns or directories,
+// and returns every file and all of their dependencies by file name
+func ParseProtos(protos []string, imports []string) (map[string]*desc.FileDescriptor, error) {
+	var filenames, dirs []string
+	for _, proto := range protos {
+		names, dir, err := expandProto(proto)
+		if err != nil {
+			return nil, err
+		}
+
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+
+		filenames = append(filenames, names...)
+	}
+
+	p := &protoparse.Parser{ImportPaths: importPaths(imports, dirs)}
 
 	files := map[string]*desc.FileDescriptor{}
-	files[fileDesc.GetName()] = fileDesc
+	for _, filename := range filenames {
+		// every file is parsed on its own, so that a file imported by another
+		// under a different name than it was given with is not a duplicate
+		fds, err := p.ParseFiles(filename)
+		if err != nil {
+			return nil, err
+		}
 
-	return getMethodDesc(call, files)
+		addFileDescriptor(files, fds[0])
+	}
+
+	return files, nil
+}
+
+// importPaths returns the import paths with the directories of the protos
+// added, without duplicates. The given import paths are left unchanged.
+func importPaths(imports, dirs []string) []string {
+	res := append([]string(nil), imports...)
+	if len(dirs) > 0 && len(imports) == 0 {
+		// keep the files given relative to the working directory
+		res = append(res, ".")
+	}
+	res = append(res, dirs...)
+
+	seen := make(map[string]bool, len(res))
+	unique := res[:0]
+	for _, path := range res {
+		if !seen[filepath.Clean(path)] {
+			seen[filepath.Clean(path)] = true
+			unique = append(unique, path)
+		}
+	}
+
+	return unique
+}
+
+// expandProto returns the names to parse the proto files by, and the directory
+// to add to the import paths for a directory
+func expandProto(proto string) ([]string, string, error) {
+	if fi, err := os.Stat(proto); err == nil && fi.IsDir() {
+		var names []string
+		err := filepath.Walk(proto, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !info.IsDir() && filepath.Ext(path) == ".proto" {
+				name, err := filepath.Rel(proto, path)
+				if err != nil {
+					return err
+				}
+				names = append(names, name)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(names) == 0 {
+			return nil, "", fmt.Errorf("no proto files in directory %q", proto)
+		}
+
+		return names, proto, nil
+	}
+
+	paths := []string{proto}
+	if strings.ContainsAny(proto, "*?[") {
+		var err error
+		paths, err = filepath.Glob(proto)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(paths) == 0 {
+			return nil, "", fmt.Errorf("no proto files match %q", proto)
+		}
+	}
+
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = path
+		if filepath.IsAbs(path) {
+			names[i] = filepath.Base(path)
+		}
+	}
+
+	return names, "", nil
+}
+
+func addFileDescriptor(files map[string]*desc.FileDescriptor, fd *desc.FileDescriptor) {
+	if _, ok := files[fd.GetName()]; ok {
+		return
+	}
+
+	files[fd.GetName()] = fd
+	for _, dep := range fd.GetDependencies() {
+		addFileDescriptor(files, dep)
+	}
 }
 
 // GetMethodDescFromProtoSet gets method descritor for the given call symbol from protoset file given my path protoset
 func GetMethodDescFromProtoSet(call, protoset string) (*desc.MethodDescriptor, error) {
+	files, err := ParseProtoSet(protoset)
+	if err != nil {
+		return nil, err
+	}
+
+	return getMethodDesc(call, files)
+}
+
+// ParseProtoSet parses the protoset file and returns every file in it by file name
+func ParseProtoSet(protoset string) (map[string]*desc.FileDescriptor, error) {
 	b, err := ioutil.ReadFile(protoset)
 	if err != nil {
 		return nil, fmt.Errorf("could not load protoset file %q: %v", protoset, err)
@@ -60,7 +191,66 @@ func GetMethodDescFromProtoSet(call, protoset string) (*desc.MethodDescriptor, e
 		}
 	}
 
-	return getMethodDesc(call, resolved)
+	return resolved, nil
+}
+
+// GetServices returns all the services in the files sorted by fully qualified name
+func GetServices(files map[string]*desc.FileDescriptor) []*desc.ServiceDescriptor {
+	var res []*desc.ServiceDescriptor
+	for _, fd := range files {
+		res = append(res, fd.GetServices()...)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].GetFullyQualifiedName() < res[j].GetFullyQualifiedName()
+	})
+
+	return res
+}
+
+// GetMethodDescFromReflect gets method descritor for the given call symbol using the server reflection client.
+// The file of the service is resolved along with all of its dependencies
+func GetMethodDescFromReflect(call string, client *grpcreflect.Client) (*desc.MethodDescriptor, error) {
+	svc, mth := parseSymbol(call)
+	if svc == "" || mth == "" {
+		return nil, fmt.Errorf("given method name %q is not in expected format: 'service/method' or 'service.method'", call)
+	}
+
+	sd, err := client.ResolveService(svc)
+	if err != nil {
+		if grpcreflect.IsElementNotFoundError(err) {
+			services, _ := client.ListServices()
+			sort.Strings(services)
+			return nil, fmt.Errorf("cannot find service %q, available services: %s", svc, strings.Join(services, ", "))
+		}
+		return nil, fmt.Errorf("could not resolve service %q with server reflection: %v", svc, err)
+	}
+
+	files := map[string]*desc.FileDescriptor{}
+	files[sd.GetFile().GetName()] = sd.GetFile()
+
+	return getMethodDesc(call, files)
+}
+
+// GetServicesFromReflect returns all the services of the server reflection client
+// sorted by fully qualified name
+func GetServicesFromReflect(client *grpcreflect.Client) ([]*desc.ServiceDescriptor, error) {
+	names, err := client.ListServices()
+	if err != nil {
+		return nil, fmt.Errorf("could not list services with server reflection: %v", err)
+	}
+	sort.Strings(names)
+
+	var res []*desc.ServiceDescriptor
+	for _, name := range names {
+		sd, err := client.ResolveService(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve service %q with server reflection: %v", name, err)
+		}
+		res = append(res, sd)
+	}
+
+	return res, nil
 }
 
 func getMethodDesc(call string, files map[string]*desc.FileDescriptor) (*desc.MethodDescriptor, error) {
@@ -73,23 +263,33 @@ func getMethodDesc(call string, files map[string]*desc.FileDescriptor) (*desc.Me
 	if err != nil {
 		return nil, err
 	}
-	if dsc == nil {
-		return nil, fmt.Errorf("cannot find service %q", svc)
-	}
 
 	sd, ok := dsc.(*desc.ServiceDescriptor)
 	if !ok {
-		return nil, fmt.Errorf("cannot find service %q", svc)
+		return nil, fmt.Errorf("cannot find service %q, available services: %s", svc, strings.Join(serviceNames(files), ", "))
 	}
 
 	mtd := sd.FindMethodByName(mth)
 	if mtd == nil {
-		return nil, fmt.Errorf("service %q does not include a method named %q", svc, mth)
+		var methods []string
+		for _, m := range sd.GetMethods() {
+			methods = append(methods, m.GetName())
+		}
+		return nil, fmt.Errorf("service %q does not include a method named %q, available methods: %s", svc, mth, strings.Join(methods, ", "))
 	}
 
 	return mtd, nil
 }
 
+// serviceNames returns the sorted fully qualified names of all the services in the files
+func serviceNames(files map[string]*desc.FileDescriptor) []string {
+	var names []string
+	for _, sd := range GetServices(files) {
+		names = append(names, sd.GetFullyQualifiedName())
+	}
+	return names
+}
+
 func resolveFileDescriptor(unresolved map[string]*descriptor.FileDescriptorProto, resolved map[string]*desc.FileDescriptor, filename string) (*desc.FileDescriptor, error) {
 	if r, ok := resolved[filename]; ok {
 		return r, nil
@@ -120,7 +320,7 @@ func findServiceSymbol(resolved map[string]*desc.FileDescriptor, fullyQualifiedN
 			return dsc, nil
 		}
 	}
-	return nil, fmt.Errorf("cannot find service %q", fullyQualifiedName)
+	return nil, fmt.Errorf("cannot find service %q, available services: %s", fullyQualifiedName, strings.Join(serviceNames(resolved), ", "))
 }
 
 func parseSymbol(svcAndMethod string) (string, string) {
@@ -44,6 +44,55 @@ func TestProtodesc_GetMethodDescFromProto(t *testing.T) {
 	})
 }
 
+func TestProtodesc_GetMethodDescFromProtos(t *testing.T) {
+	t.Run("service in imported file", func(t *testing.T) {
+		md, err := GetMethodDescFromProtos("multi.Echo.Echo", []string{"../testdata/multi/api.proto"}, []string{".", "../testdata/multi"})
+		assert.NoError(t, err)
+		assert.NotNil(t, md)
+		assert.Equal(t, "multi.EchoRequest", md.GetInputType().GetFullyQualifiedName())
+	})
+
+	t.Run("multiple files", func(t *testing.T) {
+		protos := []string{"../testdata/greeter.proto", "../testdata/multi/echo.proto"}
+		imports := []string{".", "../testdata/multi"}
+
+		md, err := GetMethodDescFromProtos("helloworld.Greeter.SayHello", protos, imports)
+		assert.NoError(t, err)
+		assert.NotNil(t, md)
+
+		md, err = GetMethodDescFromProtos("multi.Echo.Echo", protos, imports)
+		assert.NoError(t, err)
+		assert.NotNil(t, md)
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		md, err := GetMethodDescFromProtos("cap.Capper.Cap", []string{"../testdata/bundle/*.proto"}, []string{".", "../testdata/bundle"})
+		assert.NoError(t, err)
+		assert.NotNil(t, md)
+
+		_, err = GetMethodDescFromProtos("cap.Capper.Cap", []string{"../testdata/bundle/*.txt"}, nil)
+		assert.EqualError(t, err, `no proto files match "../testdata/bundle/*.txt"`)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		md, err := GetMethodDescFromProtos("multi.Api.Version", []string{"../testdata/multi"}, nil)
+		assert.NoError(t, err)
+		assert.NotNil(t, md)
+	})
+
+	t.Run("unknown service lists the available services", func(t *testing.T) {
+		md, err := GetMethodDescFromProtos("multi.Unknown.Echo", []string{"../testdata/multi"}, nil)
+		assert.EqualError(t, err, `cannot find service "multi.Unknown", available services: multi.Api, multi.Echo`)
+		assert.Nil(t, md)
+	})
+
+	t.Run("unknown method lists the available methods", func(t *testing.T) {
+		md, err := GetMethodDescFromProtos("multi.Echo.Unknown", []string{"../testdata/multi"}, nil)
+		assert.EqualError(t, err, `service "multi.Echo" does not include a method named "Unknown", available methods: Echo`)
+		assert.Nil(t, md)
+	})
+}
+
 func TestProtodesc_GetMethodDescFromProtoSet(t *testing.T) {
 	t.Run("invalid path", func(t *testing.T) {
 		md, err := GetMethodDescFromProtoSet("pkg.Call", "invalid.protoset")
@@ -87,3 +136,57 @@ func TestProtodesc_GetMethodDescFromProtoSet(t *testing.T) {
 		assert.NotNil(t, md)
 	})
 }
+
+func TestProtodesc_importPaths(t *testing.T) {
+	var tests = []struct {
+		name     string
+		imports  []string
+		dirs     []string
+		expected []string
+	}{
+		{"none", nil, nil, nil},
+		{"imports", []string{"a", "b"}, nil, []string{"a", "b"}},
+		{"dirs", nil, []string{"protos"}, []string{".", "protos"}},
+		{"imports and dirs", []string{"a"}, []string{"protos"}, []string{"a", "protos"}},
+		{"duplicates", []string{"a", "./a", "protos/"}, []string{"protos", "b", "b"}, []string{"a", "protos/", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, importPaths(tt.imports, tt.dirs))
+		})
+	}
+
+	t.Run("imports unchanged", func(t *testing.T) {
+		imports := make([]string, 1, 4)
+		imports[0] = "a"
+
+		importPaths(imports, []string{"protos"})
+		importPaths(imports, []string{"other"})
+		assert.Equal(t, []string{"a", "", "", ""}, imports[:4])
+	})
+}
+
+func TestProtodesc_GetServices(t *testing.T) {
+	t.Run("protos", func(t *testing.T) {
+		files, err := ParseProtos([]string{"../testdata/multi"}, nil)
+		assert.NoError(t, err)
+
+		services := GetServices(files)
+		assert.Len(t, services, 2)
+		assert.Equal(t, "multi.Api", services[0].GetFullyQualifiedName())
+		assert.Equal(t, "multi.Echo", services[1].GetFullyQualifiedName())
+	})
+
+	t.Run("protoset", func(t *testing.T) {
+		files, err := ParseProtoSet("../testdata/bundle.protoset")
+		assert.NoError(t, err)
+
+		var names []string
+		for _, sd := range GetServices(files) {
+			names = append(names, sd.GetFullyQualifiedName())
+		}
+		assert.Contains(t, names, "helloworld.Greeter")
+		assert.Contains(t, names, "cap.Capper")
+	})
+}
@@ -0,0 +1,132 @@
+package protodesc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+)
+
+// MessageSkeleton returns the message as indented JSON with every field set
+// to a default value, as a starting point for the call data. Repeated fields
+// have a single element, maps a single entry, only the first field of each
+// oneof is set and a message nested within itself is left empty.
+func MessageSkeleton(md *desc.MessageDescriptor) ([]byte, error) {
+	return json.MarshalIndent(skeletonMessage(md, map[string]bool{}), "", "  ")
+}
+
+// skeletonObject is a JSON object that keeps the order of the fields
+type skeletonObject []skeletonField
+
+type skeletonField struct {
+	name  string
+	value interface{}
+}
+
+func (o skeletonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// skeletonMessage returns the skeleton of the message,
+// with seen holding the messages it is nested within
+func skeletonMessage(md *desc.MessageDescriptor, seen map[string]bool) interface{} {
+	name := md.GetFullyQualifiedName()
+
+	// well-known types with a JSON representation other than an object
+	switch name {
+	case "google.protobuf.Timestamp":
+		return "1970-01-01T00:00:00Z"
+	case "google.protobuf.Duration":
+		return "0s"
+	case "google.protobuf.FieldMask":
+		return ""
+	case "google.protobuf.Value":
+		return nil
+	case "google.protobuf.ListValue":
+		return []interface{}{}
+	case "google.protobuf.Struct":
+		return skeletonObject{}
+	case "google.protobuf.Any":
+		// a wrapper, as implementations disagree on whether an Empty takes a value
+		return skeletonObject{{"@type", "type.googleapis.com/google.protobuf.StringValue"}, {"value", ""}}
+	case "google.protobuf.DoubleValue", "google.protobuf.FloatValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue", "google.protobuf.StringValue",
+		"google.protobuf.BytesValue":
+		return skeletonValue(md.FindFieldByName("value"), seen)
+	}
+
+	obj := skeletonObject{}
+	if seen[name] {
+		return obj
+	}
+
+	seen[name] = true
+	defer delete(seen, name)
+
+	for _, fd := range md.GetFields() {
+		if oo := fd.GetOneOf(); oo != nil && oo.GetChoices()[0] != fd {
+			continue
+		}
+
+		obj = append(obj, skeletonField{fd.GetName(), skeletonFieldValue(fd, seen)})
+	}
+
+	return obj
+}
+
+func skeletonFieldValue(fd *desc.FieldDescriptor, seen map[string]bool) interface{} {
+	if fd.IsMap() {
+		key := fmt.Sprint(skeletonValue(fd.GetMapKeyType(), seen))
+		return skeletonObject{{key, skeletonValue(fd.GetMapValueType(), seen)}}
+	}
+
+	if fd.IsRepeated() {
+		return []interface{}{skeletonValue(fd, seen)}
+	}
+
+	return skeletonValue(fd, seen)
+}
+
+// skeletonValue returns the default value of the type of the field
+func skeletonValue(fd *desc.FieldDescriptor, seen map[string]bool) interface{} {
+	switch fd.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return false
+	case descriptor.FieldDescriptorProto_TYPE_STRING,
+		descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return ""
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		return fd.GetEnumType().GetValues()[0].GetName()
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE,
+		descriptor.FieldDescriptorProto_TYPE_GROUP:
+		return skeletonMessage(fd.GetMessageType(), seen)
+	}
+
+	return 0
+}
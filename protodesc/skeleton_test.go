@@ -0,0 +1,76 @@
+package protodesc
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtodesc_MessageSkeleton(t *testing.T) {
+	t.Run("greeter", func(t *testing.T) {
+		md, err := GetMethodDescFromProto("helloworld.Greeter.SayHello", "../testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		skeleton, err := MessageSkeleton(md.GetInputType())
+		assert.NoError(t, err)
+		assert.Equal(t, "{\n  \"name\": \"\"\n}", string(skeleton))
+	})
+
+	t.Run("all types", func(t *testing.T) {
+		md, err := GetMethodDescFromProto("random.RandomTestService.TestCall", "../testdata/random.proto", []string{"../testdata"})
+		assert.NoError(t, err)
+
+		skeleton, err := MessageSkeleton(md.GetInputType())
+		assert.NoError(t, err)
+
+		// the child messages are left empty as they nest the request within itself
+		expected := `{
+  "double_value": 0,
+  "float_value": 0,
+  "int32_value": 0,
+  "int64_value": 0,
+  "uint32_value": 0,
+  "uint64_value": 0,
+  "sint32_value": 0,
+  "fixed64_value": 0,
+  "bool_value": false,
+  "string_value": "",
+  "bytes_value": "",
+  "kind": "UNKNOWN",
+  "tags": [
+    ""
+  ],
+  "counts": {
+    "": 0
+  },
+  "child": {},
+  "children": [
+    {}
+  ],
+  "name": "",
+  "created": "1970-01-01T00:00:00Z",
+  "ttl": "0s",
+  "nickname": "",
+  "details": {
+    "@type": "type.googleapis.com/google.protobuf.StringValue",
+    "value": ""
+  },
+  "attributes": {}
+}`
+		assert.Equal(t, expected, string(skeleton))
+
+		msg := dynamic.NewMessage(md.GetInputType())
+		assert.NoError(t, jsonpb.UnmarshalString(string(skeleton), msg))
+
+		// the skeleton round-trips through jsonpb
+		out, err := (&jsonpb.Marshaler{OrigName: true, EmitDefaults: true, Indent: "  "}).MarshalToString(msg)
+		assert.NoError(t, err)
+
+		again := dynamic.NewMessage(md.GetInputType())
+		assert.NoError(t, jsonpb.UnmarshalString(out, again))
+		assert.True(t, dynamic.MessagesEqual(msg, again))
+		assert.Contains(t, out, `"@type": "type.googleapis.com/google.protobuf.StringValue"`)
+	})
+}
@@ -0,0 +1,227 @@
+package ghz
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// RandomOptions configures the random messages generated from the schema
+// of the input type, used instead of the call data.
+type RandomOptions struct {
+	// Length of string and bytes fields. Defaults to 16.
+	Length int `json:"length,omitempty"`
+
+	// Number of elements of repeated and map fields, and of the messages
+	// of a client stream. Defaults to 3.
+	Repeated int `json:"repeated,omitempty"`
+
+	// Maximum depth of nested messages, beyond which message fields are
+	// left unset. Defaults to 3.
+	Depth int `json:"depth,omitempty"`
+}
+
+func (o *RandomOptions) validate() error {
+	if o.Length < 0 {
+		return errors.New("random length must not be negative")
+	}
+
+	if o.Repeated < 0 {
+		return errors.New("random repeated must not be negative")
+	}
+
+	if o.Depth < 0 {
+		return errors.New("random depth must not be negative")
+	}
+
+	return nil
+}
+
+func (o RandomOptions) withDefaults() *RandomOptions {
+	if o.Length == 0 {
+		o.Length = 16
+	}
+
+	if o.Repeated == 0 {
+		o.Repeated = 3
+	}
+
+	if o.Depth == 0 {
+		o.Depth = 3
+	}
+
+	return &o
+}
+
+// createRandomPayloads generates random messages of the input type of the method
+func createRandomPayloads(mtd *desc.MethodDescriptor, o *RandomOptions, r *rand.Rand) (*dynamic.Message, *[]*dynamic.Message, error) {
+	g := &randomGenerator{opts: o, rand: r}
+	md := mtd.GetInputType()
+
+	if !mtd.IsClientStreaming() {
+		input, err := g.message(md, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return input, &[]*dynamic.Message{}, nil
+	}
+
+	streamInput := make([]*dynamic.Message, o.Repeated)
+	for i := range streamInput {
+		elemMsg, err := g.message(md, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		streamInput[i] = elemMsg
+	}
+
+	return nil, &streamInput, nil
+}
+
+type randomGenerator struct {
+	opts *RandomOptions
+	rand *rand.Rand
+}
+
+// message returns a random message with every field set,
+// and one of the fields of every oneof
+func (g *randomGenerator) message(md *desc.MessageDescriptor, depth int) (*dynamic.Message, error) {
+	msg := dynamic.NewMessage(md)
+
+	// well-known types with a restricted range of valid values
+	switch md.GetFullyQualifiedName() {
+	case "google.protobuf.Timestamp":
+		// within the past year
+		msg.SetFieldByName("seconds", time.Now().Unix()-g.rand.Int63n(365*24*60*60))
+		msg.SetFieldByName("nanos", g.rand.Int31n(1e9))
+		return msg, nil
+	case "google.protobuf.Duration":
+		// up to a day
+		msg.SetFieldByName("seconds", g.rand.Int63n(24*60*60))
+		msg.SetFieldByName("nanos", g.rand.Int31n(1e9))
+		return msg, nil
+	case "google.protobuf.Any":
+		// an empty message, as the server might not know any other type
+		msg.SetFieldByName("type_url", "type.googleapis.com/google.protobuf.Empty")
+		return msg, nil
+	}
+
+	for _, fd := range md.GetFields() {
+		if fd.GetOneOf() != nil {
+			continue
+		}
+
+		if err := g.field(msg, fd, depth); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, oo := range md.GetOneOfs() {
+		choices := oo.GetChoices()
+		if err := g.field(msg, choices[g.rand.Intn(len(choices))], depth); err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
+
+func (g *randomGenerator) field(msg *dynamic.Message, fd *desc.FieldDescriptor, depth int) error {
+	if fd.IsMap() {
+		for i := 0; i < g.opts.Repeated; i++ {
+			key, err := g.value(fd.GetMapKeyType(), depth)
+			if err != nil {
+				return err
+			}
+
+			val, err := g.value(fd.GetMapValueType(), depth)
+			if err != nil || val == nil {
+				return err
+			}
+
+			if err := msg.TryPutMapField(fd, key, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if fd.IsRepeated() {
+		for i := 0; i < g.opts.Repeated; i++ {
+			val, err := g.value(fd, depth)
+			if err != nil || val == nil {
+				return err
+			}
+
+			if err := msg.TryAddRepeatedField(fd, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	val, err := g.value(fd, depth)
+	if err != nil || val == nil {
+		return err
+	}
+
+	return msg.TrySetField(fd, val)
+}
+
+// value returns a random value of the type of the field,
+// nil for a message field beyond the maximum depth
+func (g *randomGenerator) value(fd *desc.FieldDescriptor, depth int) (interface{}, error) {
+	switch fd.GetType() {
+	case dpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return g.rand.NormFloat64() * 1000, nil
+	case dpb.FieldDescriptorProto_TYPE_FLOAT:
+		return float32(g.rand.NormFloat64() * 1000), nil
+	case dpb.FieldDescriptorProto_TYPE_INT32,
+		dpb.FieldDescriptorProto_TYPE_SINT32,
+		dpb.FieldDescriptorProto_TYPE_SFIXED32:
+		return int32(g.rand.Uint32()), nil
+	case dpb.FieldDescriptorProto_TYPE_INT64,
+		dpb.FieldDescriptorProto_TYPE_SINT64,
+		dpb.FieldDescriptorProto_TYPE_SFIXED64:
+		return int64(g.rand.Uint64()), nil
+	case dpb.FieldDescriptorProto_TYPE_UINT32,
+		dpb.FieldDescriptorProto_TYPE_FIXED32:
+		return g.rand.Uint32(), nil
+	case dpb.FieldDescriptorProto_TYPE_UINT64,
+		dpb.FieldDescriptorProto_TYPE_FIXED64:
+		return g.rand.Uint64(), nil
+	case dpb.FieldDescriptorProto_TYPE_BOOL:
+		return g.rand.Intn(2) == 1, nil
+	case dpb.FieldDescriptorProto_TYPE_STRING:
+		b := make([]byte, g.opts.Length)
+		for i := range b {
+			b[i] = randomStringLetters[g.rand.Intn(len(randomStringLetters))]
+		}
+		return string(b), nil
+	case dpb.FieldDescriptorProto_TYPE_BYTES:
+		// not Rand.Read, which keeps state outside of the source and so is not
+		// safe for the random source shared by the calls not made by a worker
+		b := make([]byte, g.opts.Length)
+		for i := range b {
+			b[i] = byte(g.rand.Intn(256))
+		}
+		return b, nil
+	case dpb.FieldDescriptorProto_TYPE_ENUM:
+		values := fd.GetEnumType().GetValues()
+		return values[g.rand.Intn(len(values))].GetNumber(), nil
+	case dpb.FieldDescriptorProto_TYPE_MESSAGE,
+		dpb.FieldDescriptorProto_TYPE_GROUP:
+		if depth >= g.opts.Depth {
+			return nil, nil
+		}
+		return g.message(fd.GetMessageType(), depth+1)
+	}
+
+	return nil, fmt.Errorf("unsupported type %v of field %s", fd.GetType(), fd.GetFullyQualifiedName())
+}
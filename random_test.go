@@ -0,0 +1,116 @@
+package ghz
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz/protodesc"
+)
+
+func TestRandom_createRandomPayloads(t *testing.T) {
+	mtdUnary, err := protodesc.GetMethodDescFromProto(
+		"random.RandomTestService.TestCall",
+		"./testdata/random.proto",
+		nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, mtdUnary)
+
+	mtdClientStreaming, err := protodesc.GetMethodDescFromProto(
+		"random.RandomTestService.TestStream",
+		"./testdata/random.proto",
+		nil)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, mtdClientStreaming)
+
+	opts := RandomOptions{Length: 5, Repeated: 2, Depth: 1}
+
+	t.Run("create single message for unary", func(t *testing.T) {
+		single, streaming, err := createRandomPayloads(mtdUnary, opts.withDefaults(), rand.New(rand.NewSource(1)))
+		assert.NoError(t, err)
+		assert.NotNil(t, single)
+		assert.Empty(t, *streaming)
+
+		assert.Len(t, single.GetFieldByName("string_value"), 5)
+		assert.Len(t, single.GetFieldByName("bytes_value"), 5)
+		assert.Contains(t, []int32{0, 1, 2}, single.GetFieldByName("kind"))
+		assert.Len(t, single.GetFieldByName("tags"), 2)
+		assert.NotEmpty(t, single.GetFieldByName("counts"))
+
+		// exactly one field of the oneof
+		assert.NotEqual(t, single.HasFieldName("name"), single.HasFieldName("id"))
+
+		// the nested messages stop at the maximum depth
+		child := single.GetFieldByName("child").(*dynamic.Message)
+		assert.True(t, child.HasFieldName("string_value"))
+		assert.False(t, child.HasFieldName("child"))
+		assert.Len(t, single.GetFieldByName("children"), 2)
+
+		created := single.GetFieldByName("created").(*dynamic.Message)
+		seconds := created.GetFieldByName("seconds").(int64)
+		assert.True(t, seconds <= time.Now().Unix())
+		assert.True(t, seconds > time.Now().AddDate(-1, 0, -1).Unix())
+
+		// valid for both the binary and the JSON encoding
+		b, err := single.Marshal()
+		assert.NoError(t, err)
+		msg := dynamic.NewMessage(mtdUnary.GetInputType())
+		assert.NoError(t, msg.Unmarshal(b))
+
+		_, err = single.MarshalJSONPB(&jsonpb.Marshaler{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("create stream of messages for client streaming", func(t *testing.T) {
+		single, streaming, err := createRandomPayloads(mtdClientStreaming, opts.withDefaults(), rand.New(rand.NewSource(1)))
+		assert.NoError(t, err)
+		assert.Nil(t, single)
+		assert.Len(t, *streaming, 2)
+	})
+
+	t.Run("same seed same messages", func(t *testing.T) {
+		a, _, err := createRandomPayloads(mtdUnary, opts.withDefaults(), rand.New(rand.NewSource(7)))
+		assert.NoError(t, err)
+		b, _, err := createRandomPayloads(mtdUnary, opts.withDefaults(), rand.New(rand.NewSource(7)))
+		assert.NoError(t, err)
+		c, _, err := createRandomPayloads(mtdUnary, opts.withDefaults(), rand.New(rand.NewSource(8)))
+		assert.NoError(t, err)
+
+		assert.Equal(t, a.GetFieldByName("string_value"), b.GetFieldByName("string_value"))
+		assert.Equal(t, a.GetFieldByName("int64_value"), b.GetFieldByName("int64_value"))
+		assert.NotEqual(t, a.GetFieldByName("string_value"), c.GetFieldByName("string_value"))
+		assert.Equal(t, a.GetFieldByName("bytes_value"), b.GetFieldByName("bytes_value"))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// the calls not made by a worker all share the random source of the worker 0
+		shared := newTemplateWorker(0, 7).rand
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					single, _, err := createRandomPayloads(mtdUnary, opts.withDefaults(), shared)
+					assert.NoError(t, err)
+					assert.Len(t, single.GetFieldByName("bytes_value"), 5)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		o := (&RandomOptions{}).withDefaults()
+		assert.Equal(t, &RandomOptions{Length: 16, Repeated: 3, Depth: 3}, o)
+
+		assert.Error(t, (&RandomOptions{Length: -1}).validate())
+	})
+}
@@ -0,0 +1,330 @@
+package ghz
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RecordOptions configures the recording of the calls to a file
+// for offline inspection
+type RecordOptions struct {
+	// Path of the file, which is overwritten
+	Path string `json:"path"`
+
+	// Format of the file, "jsonl" for a JSON object per call or "bin" for a
+	// length-delimited protobuf Record message per call. Defaults to jsonl.
+	Format string `json:"format,omitempty"`
+
+	// Only every Nth call is recorded. Defaults to 1.
+	Every int `json:"every,omitempty"`
+
+	// Only the calls that fail are recorded
+	ErrorsOnly bool `json:"errorsOnly,omitempty"`
+
+	// Maximum number of calls recorded. No limit if 0.
+	Max int `json:"max,omitempty"`
+}
+
+func (o *RecordOptions) validate() error {
+	if strings.TrimSpace(o.Path) == "" {
+		return errors.New("record path is required")
+	}
+
+	if o.Format != "" && o.Format != "jsonl" && o.Format != "bin" {
+		return errors.New("record format must be jsonl or bin")
+	}
+
+	if o.Every < 0 {
+		return errors.New("record every must not be negative")
+	}
+
+	if o.Max < 0 {
+		return errors.New("record max must not be negative")
+	}
+
+	return nil
+}
+
+// callRecord is a recorded call in the JSON Lines format
+type callRecord struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Call      string            `json:"call"`
+	Request   json.RawMessage   `json:"request,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Response  json.RawMessage   `json:"response,omitempty"`
+	Status    string            `json:"status"`
+	Error     string            `json:"error,omitempty"`
+	Trailers  map[string]string `json:"trailers,omitempty"`
+	Latency   time.Duration     `json:"latency"`
+}
+
+// recordMessage is a recorded call in the protobuf format, the
+// message Record described in the README
+type recordMessage struct {
+	Timestamp int64             `protobuf:"varint,1,opt,name=timestamp"`
+	Call      string            `protobuf:"bytes,2,opt,name=call"`
+	Request   [][]byte          `protobuf:"bytes,3,rep,name=request"`
+	Metadata  map[string]string `protobuf:"bytes,4,rep,name=metadata" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Response  [][]byte          `protobuf:"bytes,5,rep,name=response"`
+	Status    string            `protobuf:"bytes,6,opt,name=status"`
+	Error     string            `protobuf:"bytes,7,opt,name=error"`
+	Trailers  map[string]string `protobuf:"bytes,8,rep,name=trailers" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Latency   int64             `protobuf:"varint,9,opt,name=latency"`
+}
+
+func (m *recordMessage) Reset()         { *m = recordMessage{} }
+func (m *recordMessage) String() string { return proto.CompactTextString(m) }
+func (*recordMessage) ProtoMessage()    {}
+
+// recordedCall holds what is recorded of a call
+type recordedCall struct {
+	start   time.Time
+	latency time.Duration
+	mtd     *desc.MethodDescriptor
+
+	input       *dynamic.Message
+	streamInput *[]*dynamic.Message
+	metadata    *metadata.MD
+
+	// the messages received, all of them for server streaming
+	responses []proto.Message
+	trailer   metadata.MD
+
+	// the error of the call as returned by the server,
+	// and after the assertions were checked
+	rpcErr error
+	err    error
+}
+
+// requests returns the request messages sent
+func (c *recordedCall) requests() []*dynamic.Message {
+	if c.mtd.IsClientStreaming() {
+		if c.streamInput == nil {
+			return nil
+		}
+		return *c.streamInput
+	}
+
+	if c.input == nil {
+		return nil
+	}
+	return []*dynamic.Message{c.input}
+}
+
+// recorder writes the sampled calls to the record file
+type recorder struct {
+	opts *RecordOptions
+
+	mu      sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	calls   int
+	written int
+
+	// the first error writing the file
+	err error
+}
+
+func newRecorder(o *RecordOptions) (*recorder, error) {
+	f, err := os.Create(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recorder{opts: o, file: f, w: bufio.NewWriter(f)}, nil
+}
+
+// sample returns whether the call is to be recorded
+func (r *recorder) sample(failed bool) bool {
+	if r.opts.ErrorsOnly && !failed {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls++
+	every := r.opts.Every
+	if every > 1 && (r.calls-1)%every != 0 {
+		return false
+	}
+
+	if r.opts.Max > 0 && r.written >= r.opts.Max {
+		return false
+	}
+
+	r.written++
+	return true
+}
+
+// record writes the call if it is sampled
+func (r *recorder) record(c *recordedCall) {
+	if !r.sample(c.err != nil) {
+		return
+	}
+
+	var b []byte
+	var err error
+	if r.opts.Format == "bin" {
+		b, err = c.marshalProto()
+	} else {
+		b, err = c.marshalJSON()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		_, err = r.w.Write(b)
+	}
+
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// close flushes and closes the file, returning the first error writing it
+func (r *recorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.w.Flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+
+	if err := r.file.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+
+	return r.err
+}
+
+func (c *recordedCall) marshalJSON() ([]byte, error) {
+	rec := callRecord{
+		Timestamp: c.start,
+		Call:      c.mtd.GetFullyQualifiedName(),
+		Metadata:  joinMetadata(c.metadata),
+		Status:    status.Convert(c.rpcErr).Code().String(),
+		Trailers:  joinMetadata(&c.trailer),
+		Latency:   c.latency,
+	}
+
+	if c.err != nil {
+		rec.Error = c.err.Error()
+	}
+
+	var msgs []proto.Message
+	for _, m := range c.requests() {
+		msgs = append(msgs, m)
+	}
+
+	var err error
+	rec.Request, err = messagesToJSON(msgs, c.mtd.IsClientStreaming())
+	if err != nil {
+		return nil, err
+	}
+
+	rec.Response, err = messagesToJSON(c.responses, c.mtd.IsServerStreaming())
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(&rec)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+func (c *recordedCall) marshalProto() ([]byte, error) {
+	rec := &recordMessage{
+		Timestamp: c.start.UnixNano(),
+		Call:      c.mtd.GetFullyQualifiedName(),
+		Metadata:  joinMetadata(c.metadata),
+		Status:    status.Convert(c.rpcErr).Code().String(),
+		Trailers:  joinMetadata(&c.trailer),
+		Latency:   int64(c.latency),
+	}
+
+	if c.err != nil {
+		rec.Error = c.err.Error()
+	}
+
+	for _, m := range c.requests() {
+		b, err := m.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		rec.Request = append(rec.Request, b)
+	}
+
+	for _, m := range c.responses {
+		b, err := proto.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		rec.Response = append(rec.Response, b)
+	}
+
+	buf := proto.NewBuffer(nil)
+	if err := buf.EncodeMessage(rec); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// messagesToJSON returns the messages as JSON, as an array for a stream
+// and as a single message otherwise, nil if there are none
+func messagesToJSON(msgs []proto.Message, stream bool) (json.RawMessage, error) {
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+
+	m := jsonpb.Marshaler{OrigName: true, EmitDefaults: true}
+	if !stream {
+		s, err := m.MarshalToString(msgs[0])
+		return json.RawMessage(s), err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, msg := range msgs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := m.Marshal(&buf, msg); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte(']')
+
+	return json.RawMessage(buf.Bytes()), nil
+}
+
+// joinMetadata returns the metadata with the values of each key joined with ", "
+func joinMetadata(md *metadata.MD) map[string]string {
+	if md == nil || len(*md) == 0 {
+		return nil
+	}
+
+	res := make(map[string]string, len(*md))
+	for k, v := range *md {
+		res[k] = strings.Join(v, ", ")
+	}
+	return res
+}
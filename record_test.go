@@ -0,0 +1,206 @@
+package ghz
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz/internal/helloworld"
+	"github.com/tab1293/ghz/protodesc"
+)
+
+func TestRecorder_sample(t *testing.T) {
+	var tests = []struct {
+		name     string
+		opts     RecordOptions
+		failed   []bool
+		expected []bool
+	}{
+		{"all", RecordOptions{}, []bool{false, true, false}, []bool{true, true, true}},
+		{"every", RecordOptions{Every: 2}, []bool{false, true, false, false, true}, []bool{true, false, true, false, true}},
+		{"errors only", RecordOptions{ErrorsOnly: true}, []bool{false, true, false, true}, []bool{false, true, false, true}},
+		{"every error", RecordOptions{ErrorsOnly: true, Every: 2}, []bool{true, false, true, true}, []bool{true, false, false, true}},
+		{"max", RecordOptions{Max: 2}, []bool{false, false, false}, []bool{true, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{opts: &tt.opts}
+			var sampled []bool
+			for _, failed := range tt.failed {
+				sampled = append(sampled, r.sample(failed))
+			}
+			assert.Equal(t, tt.expected, sampled)
+		})
+	}
+}
+
+func TestRecordOptions_validate(t *testing.T) {
+	assert.NoError(t, (&RecordOptions{Path: "calls.jsonl"}).validate())
+	assert.NoError(t, (&RecordOptions{Path: "calls.bin", Format: "bin", Every: 10, Max: 100}).validate())
+	assert.EqualError(t, (&RecordOptions{}).validate(), "record path is required")
+	assert.EqualError(t, (&RecordOptions{Path: "calls", Format: "csv"}).validate(), "record format must be jsonl or bin")
+	assert.EqualError(t, (&RecordOptions{Path: "calls", Every: -1}).validate(), "record every must not be negative")
+	assert.EqualError(t, (&RecordOptions{Path: "calls", Max: -1}).validate(), "record max must not be negative")
+}
+
+func TestRequesterRecord(t *testing.T) {
+	_, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	dir, err := ioutil.TempDir("", "ghz-record")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	run := func(call string, opts *Options) *Report {
+		md, err := protodesc.GetMethodDescFromProto(call, "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		opts.Host = localhost
+		opts.C = 1
+		opts.Timeout = 20
+		opts.DialTimtout = 20
+		opts.Insecure = true
+
+		reqr, err := New(md, opts)
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		return report
+	}
+
+	readLines := func(path string) []map[string]interface{} {
+		f, err := os.Open(path)
+		assert.NoError(t, err)
+		defer f.Close()
+
+		var res []map[string]interface{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var rec map[string]interface{}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+			res = append(res, rec)
+		}
+		return res
+	}
+
+	t.Run("jsonl", func(t *testing.T) {
+		path := filepath.Join(dir, "unary.jsonl")
+		run("helloworld.Greeter.SayHello", &Options{
+			N:        5,
+			Data:     map[string]interface{}{"name": "bob"},
+			Metadata: &map[string]string{"trace": "{{.RequestNumber}}"},
+			Record:   &RecordOptions{Path: path, Every: 2},
+		})
+
+		records := readLines(path)
+		assert.Len(t, records, 3)
+
+		rec := records[0]
+		assert.Equal(t, "helloworld.Greeter.SayHello", rec["call"])
+		assert.Equal(t, map[string]interface{}{"name": "bob"}, rec["request"])
+		assert.Equal(t, map[string]interface{}{"trace": "1"}, rec["metadata"])
+		assert.Equal(t, map[string]interface{}{"message": "Hello bob"}, rec["response"])
+		assert.Equal(t, "OK", rec["status"])
+		assert.NotContains(t, rec, "error")
+		assert.True(t, rec["latency"].(float64) > 0)
+		assert.NotEmpty(t, rec["timestamp"])
+		assert.Equal(t, map[string]interface{}{"trace": "3"}, records[1]["metadata"])
+	})
+
+	t.Run("server streaming", func(t *testing.T) {
+		path := filepath.Join(dir, "stream.jsonl")
+		run("helloworld.Greeter.SayHellos", &Options{
+			N:      2,
+			Data:   map[string]interface{}{"name": "bob"},
+			Record: &RecordOptions{Path: path},
+		})
+
+		records := readLines(path)
+		assert.Len(t, records, 2)
+		assert.Len(t, records[0]["response"], 4)
+	})
+
+	t.Run("errors only", func(t *testing.T) {
+		path := filepath.Join(dir, "errors.jsonl")
+		report := run("helloworld.Greeter.SayHello", &Options{
+			N:          4,
+			Data:       map[string]interface{}{"name": "bob"},
+			Assertions: []Assertion{{Path: "$.message", Equals: "Hello joe"}},
+			Record:     &RecordOptions{Path: path, ErrorsOnly: true, Max: 3},
+		})
+		assert.Len(t, report.ErrorDist, 1)
+
+		records := readLines(path)
+		assert.Len(t, records, 3)
+		assert.Equal(t, "OK", records[0]["status"])
+		assert.Equal(t, `assertion failed: $.message == "Hello joe"`, records[0]["error"])
+	})
+
+	t.Run("bin", func(t *testing.T) {
+		path := filepath.Join(dir, "unary.bin")
+		run("helloworld.Greeter.SayHello", &Options{
+			N:      3,
+			Data:   map[string]interface{}{"name": "bob"},
+			Record: &RecordOptions{Path: path, Format: "bin"},
+		})
+
+		b, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+
+		buf := proto.NewBuffer(b)
+		var records []*recordMessage
+		for {
+			rec := &recordMessage{}
+			if err := buf.DecodeMessage(rec); err != nil {
+				break
+			}
+			records = append(records, rec)
+		}
+		assert.Len(t, records, 3)
+
+		rec := records[0]
+		assert.Equal(t, "helloworld.Greeter.SayHello", rec.Call)
+		assert.Equal(t, "OK", rec.Status)
+		assert.True(t, rec.Latency > 0)
+
+		req := &helloworld.HelloRequest{}
+		assert.Len(t, rec.Request, 1)
+		assert.NoError(t, proto.Unmarshal(rec.Request[0], req))
+		assert.Equal(t, "bob", req.Name)
+
+		res := &helloworld.HelloReply{}
+		assert.Len(t, rec.Response, 1)
+		assert.NoError(t, proto.Unmarshal(rec.Response[0], res))
+		assert.Equal(t, "Hello bob", res.Message)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		reqr, err := New(md, &Options{
+			Host:     localhost,
+			N:        1,
+			C:        1,
+			Data:     map[string]interface{}{"name": "bob"},
+			Insecure: true,
+			Record:   &RecordOptions{Path: filepath.Join(dir, "missing", "calls.jsonl")},
+		})
+		assert.NoError(t, err)
+
+		_, err = reqr.Run()
+		assert.Error(t, err)
+	})
+}
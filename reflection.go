@@ -0,0 +1,64 @@
+package ghz
+
+import (
+	"context"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"github.com/tab1293/ghz/protodesc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// Reflector resolves method descriptors using the server reflection service
+// of the host, connecting with the same TLS settings and metadata as the calls.
+// The metadata is sent as is, without template evaluation.
+type Reflector struct {
+	cc     *grpc.ClientConn
+	client *grpcreflect.Client
+	cancel context.CancelFunc
+}
+
+// NewReflector connects to the host of the options
+func NewReflector(o *Options) (*Reflector, error) {
+	cc, err := dial(o)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if o.Metadata != nil && len(*o.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(*o.Metadata))
+	}
+
+	var cancel context.CancelFunc
+	if o.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(o.Timeout)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	client := grpcreflect.NewClient(ctx, rpb.NewServerReflectionClient(cc))
+
+	return &Reflector{cc: cc, client: client, cancel: cancel}, nil
+}
+
+// MethodDesc gets the method descriptor for the given call symbol,
+// resolving the file of the service along with all of its dependencies
+func (r *Reflector) MethodDesc(call string) (*desc.MethodDescriptor, error) {
+	return protodesc.GetMethodDescFromReflect(call, r.client)
+}
+
+// Services gets the descriptors of all the services of the host
+func (r *Reflector) Services() ([]*desc.ServiceDescriptor, error) {
+	return protodesc.GetServicesFromReflect(r.client)
+}
+
+// Close closes the reflection stream and the connection
+func (r *Reflector) Close() error {
+	r.client.Reset()
+	r.cancel()
+	return r.cc.Close()
+}
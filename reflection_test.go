@@ -0,0 +1,93 @@
+package ghz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz/internal/helloworld"
+)
+
+func TestReflector(t *testing.T) {
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	reflector, err := NewReflector(&Options{
+		Host:        localhost,
+		Timeout:     20,
+		DialTimtout: 20,
+		Metadata:    &map[string]string{"token": "asdf"},
+		Insecure:    true,
+	})
+	assert.NoError(t, err)
+	defer reflector.Close()
+
+	t.Run("unary", func(t *testing.T) {
+		mtd, err := reflector.MethodDesc("helloworld.Greeter.SayHello")
+		assert.NoError(t, err)
+		assert.NotNil(t, mtd)
+		assert.Equal(t, "helloworld.Greeter.SayHello", mtd.GetFullyQualifiedName())
+		assert.Equal(t, "helloworld.HelloRequest", mtd.GetInputType().GetFullyQualifiedName())
+	})
+
+	t.Run("client streaming", func(t *testing.T) {
+		mtd, err := reflector.MethodDesc("helloworld.Greeter/SayHelloCS")
+		assert.NoError(t, err)
+		assert.NotNil(t, mtd)
+		assert.True(t, mtd.IsClientStreaming())
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		mtd, err := reflector.MethodDesc("helloworld.Unknown.SayHello")
+		assert.EqualError(t, err, `cannot find service "helloworld.Unknown", available services: grpc.reflection.v1alpha.ServerReflection, helloworld.Greeter`)
+		assert.Nil(t, mtd)
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		mtd, err := reflector.MethodDesc("helloworld.Greeter.Unknown")
+		assert.EqualError(t, err, `service "helloworld.Greeter" does not include a method named "Unknown", available methods: SayHello, SayHelloCS, SayHellos, SayHelloBidi`)
+		assert.Nil(t, mtd)
+	})
+
+	t.Run("services", func(t *testing.T) {
+		services, err := reflector.Services()
+		assert.NoError(t, err)
+		assert.Len(t, services, 2)
+		assert.Equal(t, "grpc.reflection.v1alpha.ServerReflection", services[0].GetFullyQualifiedName())
+		assert.Equal(t, "helloworld.Greeter", services[1].GetFullyQualifiedName())
+		assert.Len(t, services[1].GetMethods(), 4)
+	})
+
+	t.Run("invalid symbol", func(t *testing.T) {
+		_, err := reflector.MethodDesc("SayHello")
+		assert.Error(t, err)
+	})
+
+	t.Run("run with the resolved method", func(t *testing.T) {
+		gs.ResetCounters()
+
+		mtd, err := reflector.MethodDesc("helloworld.Greeter.SayHello")
+		assert.NoError(t, err)
+
+		reqr, err := New(mtd, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Data:        map[string]interface{}{"name": "bob"},
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, int(report.Count))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Equal(t, 10, gs.GetCount(helloworld.Unary))
+	})
+}
@@ -2,25 +2,62 @@ package ghz
 
 import (
 	"encoding/json"
-	"sort"
+	"math"
+	"sync"
 	"time"
+
+	"github.com/codahale/hdrhistogram"
+)
+
+// The range of the latencies tracked by the histograms in microseconds,
+// longer calls are counted as taking the maximum
+const (
+	minLatency = 1
+	maxLatency = int64(time.Hour / time.Microsecond)
 )
 
+// The significant figures of the histograms by default
+const defaultPrecision = 3
+
 // Reporter gethers all the results
 type Reporter struct {
 	options *Options
 	results chan *callResult
 	done    chan bool
 
-	avgTotal float64
+	// the live metrics the results are recorded in, nil when they are not served
+	metrics *metrics
+
+	precision   int
+	percentiles []float64
 
-	lats     []float64
-	errors   []string
-	statuses []string
+	lats     *latencyStats
+	respLats *latencyStats
+	details  []ResultDetail
 
 	errorDist      map[string]int
 	statusCodeDist map[string]int
+	assertionDist  map[string]int
 	totalCount     uint64
+
+	// the latency of the calls by the status code they ended with
+	statusLats map[string]*latencyStats
+
+	timeline *timeline
+
+	// the progress of the run, guarded by mu as it is read while the
+	// results are gathered: the calls that ended since the last
+	// snapshot, the ones of the latest snapshots, the failed calls by
+	// status code and the median latency of every snapshot
+	mu           sync.Mutex
+	window       *latencyStats
+	windowStart  time.Duration
+	windows      []progressWindow
+	statusErrors map[string]int
+	history      []time.Duration
+
+	stages []*aggregate
+	calls  []*aggregate
 }
 
 // Report holds the data for the full test
@@ -34,10 +71,29 @@ type Report struct {
 	Fastest time.Duration `json:"fastest"`
 	Slowest time.Duration `json:"slowest"`
 	Rps     float64       `json:"rps"`
+	Dropped uint64        `json:"dropped,omitempty"`
+
+	ResponseTime *ResponseTime `json:"responseTime,omitempty"`
+
+	Stages []StageReport `json:"stages,omitempty"`
+	Calls  []CallReport  `json:"calls,omitempty"`
+	Steps  []CallReport  `json:"steps,omitempty"`
 
 	ErrorDist      map[string]int `json:"errorDistribution"`
 	StatusCodeDist map[string]int `json:"statusCodeDistribution"`
 
+	// The number of calls that failed each assertion by name
+	AssertionDist map[string]int `json:"assertionDistribution,omitempty"`
+
+	// The latency of the calls by the status code they ended with
+	StatusLatency map[string]StatusLatency `json:"statusLatency,omitempty"`
+
+	// The results of the calls by the interval of the run they ended in
+	Intervals []Interval `json:"intervals,omitempty"`
+
+	// The outcome of the thresholds of the options
+	Thresholds []ThresholdResult `json:"thresholds,omitempty"`
+
 	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
 	Histogram           []Bucket              `json:"histogram"`
 	Details             []ResultDetail        `json:"details"`
@@ -55,9 +111,60 @@ func (r Report) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// ResponseTime holds latency measured from the intended start of each call
+// rather than from when it was actually sent, so it includes any time spent
+// queued behind the rate limit or a busy worker.
+// It is only present when the calls are paced by a rate limit.
+type ResponseTime struct {
+	Average             time.Duration         `json:"average"`
+	Fastest             time.Duration         `json:"fastest"`
+	Slowest             time.Duration         `json:"slowest"`
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+}
+
+// StatusLatency holds the latency of the calls that ended with a status code
+type StatusLatency struct {
+	Count               uint64                `json:"count"`
+	Average             time.Duration         `json:"average"`
+	Fastest             time.Duration         `json:"fastest"`
+	Slowest             time.Duration         `json:"slowest"`
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+}
+
+// Summary holds the aggregated results of a subset of the calls
+type Summary struct {
+	Count   uint64        `json:"count"`
+	Total   time.Duration `json:"total"`
+	Average time.Duration `json:"average"`
+	Fastest time.Duration `json:"fastest"`
+	Slowest time.Duration `json:"slowest"`
+	Rps     float64       `json:"rps"`
+
+	ErrorDist      map[string]int `json:"errorDistribution"`
+	StatusCodeDist map[string]int `json:"statusCodeDistribution"`
+	AssertionDist  map[string]int `json:"assertionDistribution,omitempty"`
+
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+}
+
+// StageReport holds the results of a single stage of the load profile
+type StageReport struct {
+	Stage Stage `json:"stage"`
+	Summary
+}
+
+// CallReport holds the results of a single call of the traffic mix
+// or step of the sequence
+type CallReport struct {
+	Name   string `json:"name"`
+	Method string `json:"call"`
+	Weight int    `json:"weight"`
+	Summary
+}
+
 // LatencyDistribution holds latency distribution data
 type LatencyDistribution struct {
-	Percentage int           `json:"percentage"`
+	Percentage float64       `json:"percentage"`
 	Latency    time.Duration `json:"latency"`
 }
 
@@ -75,53 +182,111 @@ type Bucket struct {
 
 // ResultDetail data for each result
 type ResultDetail struct {
-	Latency time.Duration `json:"latency"`
-	Error   string        `json:"error"`
-	Status  string        `json:"status"`
+	Timestamp time.Time     `json:"timestamp"`
+	Latency   time.Duration `json:"latency"`
+	Error     string        `json:"error"`
+	Status    string        `json:"status"`
 }
 
-func newReporter(results chan *callResult, options *Options) *Reporter {
-	cap := min(options.N, maxResult)
+func newReporter(results chan *callResult, options *Options, start time.Time) *Reporter {
+	precision := options.Precision
+	if precision == 0 {
+		precision = defaultPrecision
+	}
+	percentiles := sloPercentiles(options.Percentiles, options.Thresholds)
+	stages := make([]*aggregate, len(options.Stages))
+	for i := range stages {
+		stages[i] = newAggregate(precision, percentiles)
+	}
+	numCalls := len(options.Calls)
+	if len(options.Steps) > 0 {
+		numCalls = len(options.Steps)
+	}
+	calls := make([]*aggregate, numCalls)
+	for i := range calls {
+		calls[i] = newAggregate(precision, percentiles)
+	}
 	return &Reporter{
 		options:        options,
 		results:        results,
 		done:           make(chan bool, 1),
+		precision:      precision,
+		percentiles:    percentiles,
+		lats:           newLatencyStats(precision),
+		respLats:       newLatencyStats(precision),
 		statusCodeDist: make(map[string]int),
 		errorDist:      make(map[string]int),
-		lats:           make([]float64, 0, cap),
+		assertionDist:  make(map[string]int),
+		statusLats:     make(map[string]*latencyStats),
+		timeline:       newTimeline(start, options.Interval, precision, percentiles),
+		window:         newLatencyStats(precision),
+		statusErrors:   make(map[string]int),
+		stages:         stages,
+		calls:          calls,
 	}
 }
 
 // Run runs the reporter
 func (r *Reporter) Run() {
 	for res := range r.results {
-		r.totalCount++
-		if res.err != nil {
-			errStr := res.err.Error()
-			r.errorDist[errStr]++
-
-			if len(r.errors) < maxResult {
-				r.errors = append(r.errors, errStr)
-				r.statuses = append(r.statuses, res.status)
-			}
-		} else {
-			r.avgTotal += res.duration.Seconds()
-			r.statusCodeDist[res.status]++
-
-			if len(r.lats) < maxResult {
-				r.lats = append(r.lats, res.duration.Seconds())
-				r.errors = append(r.errors, "")
-				r.statuses = append(r.statuses, res.status)
-			}
-		}
+		r.add(res)
 	}
 	r.done <- true
 }
 
+func (r *Reporter) add(res *callResult) {
+	if r.metrics != nil {
+		r.metrics.record(res)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.totalCount++
+
+	if res.stage > 0 && res.stage <= len(r.stages) {
+		r.stages[res.stage-1].add(res)
+	}
+
+	if res.call > 0 && res.call <= len(r.calls) {
+		r.calls[res.call-1].add(res)
+	}
+
+	for _, name := range res.assertions {
+		r.assertionDist[name]++
+	}
+
+	r.lats.add(res.duration)
+	r.window.add(res.duration)
+	r.timeline.add(res)
+
+	sl, ok := r.statusLats[res.status]
+	if !ok {
+		sl = newLatencyStats(r.precision)
+		r.statusLats[res.status] = sl
+	}
+	sl.add(res.duration)
+
+	errStr := ""
+	if res.err != nil {
+		errStr = res.err.Error()
+		r.errorDist[errStr]++
+		r.statusErrors[res.status]++
+	} else {
+		r.statusCodeDist[res.status]++
+	}
+
+	if res.responseTime > 0 {
+		r.respLats.add(res.responseTime)
+	}
+
+	if len(r.details) < r.options.Details {
+		r.details = append(r.details, ResultDetail{Timestamp: res.end, Latency: res.duration, Error: errStr, Status: res.status})
+	}
+}
+
 // Finalize all the gathered data into a final report
 func (r *Reporter) Finalize(total time.Duration) *Report {
-	average := r.avgTotal / float64(r.totalCount)
-	avgDuration := time.Duration(average * float64(time.Second))
 	rps := float64(r.totalCount) / total.Seconds()
 
 	rep := &Report{
@@ -129,86 +294,278 @@ func (r *Reporter) Finalize(total time.Duration) *Report {
 		Date:           time.Now(),
 		Count:          r.totalCount,
 		Total:          total,
-		Average:        avgDuration,
+		Average:        r.lats.average(),
 		Rps:            rps,
 		ErrorDist:      r.errorDist,
-		StatusCodeDist: r.statusCodeDist}
-
-	if len(r.lats) > 0 {
-		lats := make([]float64, len(r.lats))
-		copy(lats, r.lats)
-		sort.Float64s(lats)
-
-		var fastestNum, slowestNum float64
-		fastestNum = lats[0]
-		slowestNum = lats[len(lats)-1]
-
-		rep.Fastest = time.Duration(fastestNum * float64(time.Second))
-		rep.Slowest = time.Duration(slowestNum * float64(time.Second))
-		rep.Histogram = histogram(&lats, slowestNum, fastestNum)
-		rep.LatencyDistribution = latencies(&lats)
-
-		rep.Details = make([]ResultDetail, len(r.lats))
-		for i, num := range r.lats {
-			lat := time.Duration(num * float64(time.Second))
-			rep.Details[i] = ResultDetail{Latency: lat, Error: r.errors[i], Status: r.statuses[i]}
+		StatusCodeDist: r.statusCodeDist,
+		AssertionDist:  r.assertionDist,
+		Details:        r.details}
+
+	if r.lats.count > 0 {
+		rep.Fastest = r.lats.fastest
+		rep.Slowest = r.lats.slowest
+		rep.Histogram = r.lats.histogram(r.options.Buckets)
+		rep.LatencyDistribution = r.lats.latencies(r.percentiles)
+	}
+
+	if r.totalCount > 0 {
+		rep.Intervals = r.timeline.finish(total)
+	}
+
+	if len(r.statusLats) > 0 {
+		rep.StatusLatency = make(map[string]StatusLatency, len(r.statusLats))
+		for code, sl := range r.statusLats {
+			rep.StatusLatency[code] = sl.summary(r.percentiles)
+		}
+	}
+
+	if r.respLats.count > 0 {
+		rep.ResponseTime = &ResponseTime{
+			Average:             r.respLats.average(),
+			Fastest:             r.respLats.fastest,
+			Slowest:             r.respLats.slowest,
+			LatencyDistribution: r.respLats.latencies(r.percentiles),
 		}
 	}
 
 	return rep
 }
 
-func latencies(latencies *[]float64) []LatencyDistribution {
-	lats := *latencies
-	pctls := []int{10, 25, 50, 75, 90, 95, 99}
-	data := make([]float64, len(pctls))
-	j := 0
-	for i := 0; i < len(lats) && j < len(pctls); i++ {
-		current := i * 100 / len(lats)
-		if current >= pctls[j] {
-			data[j] = lats[i]
-			j++
+// stageReports summarizes the results of each stage given the stages
+// and the time each one actually ran for
+func (r *Reporter) stageReports(stages []Stage, totals []time.Duration) []StageReport {
+	res := make([]StageReport, 0, len(totals))
+	for i, total := range totals {
+		if i >= len(stages) || i >= len(r.stages) {
+			break
 		}
+		res = append(res, StageReport{Stage: stages[i], Summary: r.stages[i].summary(total)})
 	}
-	res := make([]LatencyDistribution, len(pctls))
-	for i := 0; i < len(pctls); i++ {
-		if data[i] > 0 {
-			lat := time.Duration(data[i] * float64(time.Second))
-			res[i] = LatencyDistribution{Percentage: pctls[i], Latency: lat}
+	return res
+}
+
+// callReports summarizes the results of each call of the traffic mix
+// or each step of the sequence
+func (r *Reporter) callReports(calls []*scenarioCall, total time.Duration) []CallReport {
+	res := make([]CallReport, 0, len(calls))
+	for i, c := range calls {
+		if i >= len(r.calls) {
+			break
 		}
+		res = append(res, CallReport{
+			Name:    c.name,
+			Method:  c.mtd.GetFullyQualifiedName(),
+			Weight:  c.weight,
+			Summary: r.calls[i].summary(total),
+		})
 	}
 	return res
 }
 
-func histogram(latencies *[]float64, slowest, fastest float64) []Bucket {
-	lats := *latencies
-	bc := 10
-	buckets := make([]float64, bc+1)
-	counts := make([]int, bc+1)
-	bs := (slowest - fastest) / float64(bc)
-	for i := 0; i < bc; i++ {
-		buckets[i] = fastest + bs*float64(i)
+// aggregate accumulates the results of a subset of the calls
+type aggregate struct {
+	lats        *latencyStats
+	percentiles []float64
+
+	errorDist      map[string]int
+	statusCodeDist map[string]int
+	assertionDist  map[string]int
+}
+
+func newAggregate(precision int, percentiles []float64) *aggregate {
+	return &aggregate{
+		lats:           newLatencyStats(precision),
+		percentiles:    percentiles,
+		errorDist:      make(map[string]int),
+		statusCodeDist: make(map[string]int),
+		assertionDist:  make(map[string]int),
+	}
+}
+
+func (a *aggregate) add(res *callResult) {
+	for _, name := range res.assertions {
+		a.assertionDist[name]++
+	}
+
+	if res.err != nil {
+		a.errorDist[res.err.Error()]++
+	} else {
+		a.statusCodeDist[res.status]++
+	}
+
+	a.lats.add(res.duration)
+}
+
+func (a *aggregate) summary(total time.Duration) Summary {
+	s := Summary{
+		Count:          a.lats.count,
+		Total:          total,
+		ErrorDist:      a.errorDist,
+		StatusCodeDist: a.statusCodeDist,
+		AssertionDist:  a.assertionDist,
+	}
+
+	if total > 0 {
+		s.Rps = float64(a.lats.count) / total.Seconds()
+	}
+
+	if a.lats.count > 0 {
+		s.Average = a.lats.average()
+		s.Fastest = a.lats.fastest
+		s.Slowest = a.lats.slowest
+		s.LatencyDistribution = a.lats.latencies(a.percentiles)
+	}
+
+	return s
+}
+
+// latencyStats accumulates the latency of a set of calls. The fastest,
+// slowest and average are exact, while the percentiles and the histogram
+// come from a high dynamic range histogram with the given significant
+// figures, so the memory used does not grow with the number of calls.
+type latencyStats struct {
+	count   uint64
+	total   float64
+	fastest time.Duration
+	slowest time.Duration
+	hist    *hdrhistogram.Histogram
+}
+
+func newLatencyStats(precision int) *latencyStats {
+	return &latencyStats{hist: hdrhistogram.New(minLatency, maxLatency, precision)}
+}
+
+func (l *latencyStats) add(d time.Duration) {
+	if l.count == 0 || d < l.fastest {
+		l.fastest = d
 	}
-	buckets[bc] = slowest
+	if d > l.slowest {
+		l.slowest = d
+	}
+	l.count++
+	l.total += d.Seconds()
+
+	v := int64(d / time.Microsecond)
+	if v > maxLatency {
+		v = maxLatency
+	}
+	l.hist.RecordValue(v)
+}
+
+// merge adds the calls of another set to the set
+func (l *latencyStats) merge(o *latencyStats) {
+	if o.count == 0 {
+		return
+	}
+	if l.count == 0 || o.fastest < l.fastest {
+		l.fastest = o.fastest
+	}
+	if o.slowest > l.slowest {
+		l.slowest = o.slowest
+	}
+	l.count += o.count
+	l.total += o.total
+	l.hist.Merge(o.hist)
+}
+
+func (l *latencyStats) reset() {
+	l.count = 0
+	l.total = 0
+	l.fastest = 0
+	l.slowest = 0
+	l.hist.Reset()
+}
+
+func (l *latencyStats) average() time.Duration {
+	if l.count == 0 {
+		return 0
+	}
+	return time.Duration(l.total / float64(l.count) * float64(time.Second))
+}
+
+// clamp bounds a value read from the histogram by the exact fastest and slowest,
+// which the calls longer than the histogram tracks are counted as
+func (l *latencyStats) clamp(us int64) time.Duration {
+	d := time.Duration(us) * time.Microsecond
+	if us >= maxLatency {
+		return l.slowest
+	}
+	if d < l.fastest {
+		return l.fastest
+	}
+	if d > l.slowest {
+		return l.slowest
+	}
+	return d
+}
+
+func (l *latencyStats) summary(pctls []float64) StatusLatency {
+	s := StatusLatency{Count: l.count}
+	if l.count == 0 {
+		return s
+	}
+
+	s.Average = l.average()
+	s.Fastest = l.fastest
+	s.Slowest = l.slowest
+	s.LatencyDistribution = l.latencies(pctls)
+	return s
+}
+
+// latencies returns the nearest-rank percentiles of the latency,
+// given in increasing order
+func (l *latencyStats) latencies(pctls []float64) []LatencyDistribution {
+	res := make([]LatencyDistribution, len(pctls))
+	for i, p := range pctls {
+		res[i].Percentage = p
+	}
+	if l.count == 0 {
+		return res
+	}
+
+	bars := l.hist.Distribution()
 	var bi int
-	var max int
-	for i := 0; i < len(lats); {
-		if lats[i] <= buckets[bi] {
-			i++
-			counts[bi]++
-			if max < counts[bi] {
-				max = counts[bi]
-			}
-		} else if bi < len(buckets)-1 {
+	var seen int64
+	for i, p := range pctls {
+		// the tolerance keeps the rank of 99.9 of 10000 calls at 9990
+		rank := int64(math.Ceil(p/100*float64(l.count) - 1e-9))
+		if rank < 1 {
+			rank = 1
+		}
+		for bi < len(bars)-1 && seen+bars[bi].Count < rank {
+			seen += bars[bi].Count
 			bi++
 		}
+		res[i].Latency = l.clamp(bars[bi].To)
 	}
+	return res
+}
+
+// histogram returns the counts of the latency in the buckets of the layout
+func (l *latencyStats) histogram(b *Buckets) []Bucket {
+	buckets := b.marks(l.fastest.Seconds(), l.slowest.Seconds())
+	counts := make([]int, len(buckets))
+	last := len(buckets) - 1
+
+	// the values are sorted so each falls in the same bucket or a later one
+	var bi int
+	for _, bar := range l.hist.Distribution() {
+		if bar.Count == 0 {
+			continue
+		}
+		lat := l.clamp(bar.To).Seconds()
+		for lat > buckets[bi] && bi < last {
+			bi++
+		}
+		counts[bi] += int(bar.Count)
+	}
+
 	res := make([]Bucket, len(buckets))
 	for i := 0; i < len(buckets); i++ {
 		res[i] = Bucket{
 			Mark:      buckets[i],
 			Count:     counts[i],
-			Frequency: float64(counts[i]) / float64(len(lats)),
+			Frequency: float64(counts[i]) / float64(l.count),
 		}
 	}
 	return res
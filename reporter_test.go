@@ -2,6 +2,7 @@ package ghz
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -26,3 +27,143 @@ func TestReport_MarshalJSON(t *testing.T) {
 	expected := `{"date":"2006-01-02T15:04:00-07:00","count":1000,"total":10000000000,"average":500000000,"fastest":10000000,"slowest":1000000000,"rps":34567.89,"errorDistribution":null,"statusCodeDistribution":null,"latencyDistribution":null,"histogram":null,"details":null}`
 	assert.Equal(t, expected, string(json))
 }
+
+func TestReporter_aggregate(t *testing.T) {
+	a := newAggregate(defaultPrecision, defaultPercentiles)
+	a.add(&callResult{status: "OK", duration: 10 * time.Millisecond})
+	a.add(&callResult{status: "OK", duration: 30 * time.Millisecond})
+	a.add(&callResult{status: "OK", duration: 20 * time.Millisecond})
+	a.add(&callResult{err: errors.New("boom"), status: "Unknown", duration: time.Millisecond})
+
+	s := a.summary(2 * time.Second)
+	assert.Equal(t, uint64(4), s.Count)
+	assert.Equal(t, 2*time.Second, s.Total)
+	assert.Equal(t, 2.0, s.Rps)
+	assert.Equal(t, time.Millisecond, s.Fastest)
+	assert.Equal(t, 30*time.Millisecond, s.Slowest)
+	assert.Equal(t, 15250*time.Microsecond, s.Average)
+	assert.Equal(t, map[string]int{"OK": 3}, s.StatusCodeDist)
+	assert.Equal(t, map[string]int{"boom": 1}, s.ErrorDist)
+	assert.NotEmpty(t, s.LatencyDistribution)
+}
+
+func TestReporter_failedCalls(t *testing.T) {
+	results := make(chan *callResult, 4)
+	r := newReporter(results, &Options{N: 4, Details: 10}, time.Now())
+
+	results <- &callResult{status: "OK", duration: 10 * time.Millisecond}
+	results <- &callResult{err: errors.New("unavailable"), status: "Unavailable", duration: 2 * time.Millisecond}
+	results <- &callResult{status: "OK", duration: 30 * time.Millisecond}
+	results <- &callResult{err: errors.New("deadline exceeded"), status: "DeadlineExceeded", duration: 50 * time.Millisecond}
+	close(results)
+	r.Run()
+
+	rep := r.Finalize(time.Second)
+	assert.Equal(t, uint64(4), rep.Count)
+	assert.Equal(t, 23*time.Millisecond, rep.Average)
+	assert.Equal(t, 2*time.Millisecond, rep.Fastest)
+	assert.Equal(t, 50*time.Millisecond, rep.Slowest)
+	assert.Equal(t, map[string]int{"OK": 2}, rep.StatusCodeDist)
+
+	assert.Equal(t, []ResultDetail{
+		{Latency: 10 * time.Millisecond, Status: "OK"},
+		{Latency: 2 * time.Millisecond, Error: "unavailable", Status: "Unavailable"},
+		{Latency: 30 * time.Millisecond, Status: "OK"},
+		{Latency: 50 * time.Millisecond, Error: "deadline exceeded", Status: "DeadlineExceeded"},
+	}, rep.Details)
+
+	assert.Len(t, rep.StatusLatency, 3)
+	ok := rep.StatusLatency["OK"]
+	assert.Equal(t, uint64(2), ok.Count)
+	assert.Equal(t, 20*time.Millisecond, ok.Average)
+	assert.Equal(t, 10*time.Millisecond, ok.Fastest)
+	assert.Equal(t, 30*time.Millisecond, ok.Slowest)
+	assert.Len(t, ok.LatencyDistribution, 7)
+
+	unavailable := rep.StatusLatency["Unavailable"]
+	assert.Equal(t, uint64(1), unavailable.Count)
+	assert.Equal(t, 2*time.Millisecond, unavailable.Average)
+	assert.Equal(t, 2*time.Millisecond, unavailable.LatencyDistribution[6].Latency)
+}
+
+func TestReporter_latencies(t *testing.T) {
+	l := newLatencyStats(5)
+	for i := 0; i < 51; i++ {
+		l.add(time.Duration(i+1) * time.Millisecond)
+	}
+
+	ld := l.latencies(defaultPercentiles)
+	assert.Len(t, ld, 7)
+	assert.Equal(t, LatencyDistribution{Percentage: 10, Latency: 6 * time.Millisecond}, ld[0])
+	assert.Equal(t, LatencyDistribution{Percentage: 50, Latency: 26 * time.Millisecond}, ld[2])
+	assert.Equal(t, LatencyDistribution{Percentage: 99, Latency: 51 * time.Millisecond}, ld[6])
+}
+
+func TestReporter_percentiles(t *testing.T) {
+	l := newLatencyStats(5)
+	for i := 0; i < 10000; i++ {
+		l.add(time.Duration(i+1) * time.Microsecond)
+	}
+
+	ld := l.latencies([]float64{50, 99.9, 99.99, 100})
+	assert.Equal(t, []LatencyDistribution{
+		{Percentage: 50, Latency: 5000 * time.Microsecond},
+		{Percentage: 99.9, Latency: 9990 * time.Microsecond},
+		{Percentage: 99.99, Latency: 9999 * time.Microsecond},
+		{Percentage: 100, Latency: 10000 * time.Microsecond},
+	}, ld)
+
+	h := l.histogram(&Buckets{Layout: "explicit", Bounds: []float64{1, 5}})
+	assert.Len(t, h, 3)
+	assert.Equal(t, 1000, h[0].Count)
+	assert.Equal(t, 4000, h[1].Count)
+	assert.Equal(t, 5000, h[2].Count)
+	assert.Equal(t, 0.01, h[2].Mark)
+	assert.Equal(t, 0.5, h[2].Frequency)
+}
+
+func TestReporter_latencyStats(t *testing.T) {
+	l := newLatencyStats(defaultPrecision)
+	for i := 0; i < 2000000; i++ {
+		l.add(time.Duration(1000+i%1000) * time.Microsecond)
+	}
+	l.add(2 * time.Hour)
+
+	assert.Equal(t, uint64(2000001), l.count)
+	assert.Equal(t, time.Millisecond, l.fastest)
+	assert.Equal(t, 2*time.Hour, l.slowest)
+
+	ld := l.latencies(defaultPercentiles)
+	assert.InDelta(t, 1500*time.Microsecond, ld[2].Latency, float64(2*time.Microsecond))
+	assert.InDelta(t, 1990*time.Microsecond, ld[6].Latency, float64(2*time.Microsecond))
+
+	h := l.histogram(nil)
+	assert.Len(t, h, 11)
+	assert.Equal(t, 2000, h[0].Count)
+	assert.Equal(t, 1998000, h[1].Count)
+	assert.Equal(t, 1, h[10].Count)
+	assert.Equal(t, 2*time.Hour, l.clamp(maxLatency))
+}
+
+func TestReporter_details(t *testing.T) {
+	results := make(chan *callResult, 3)
+	r := newReporter(results, &Options{N: 3, Details: 2}, time.Now())
+
+	for i := 0; i < 3; i++ {
+		results <- &callResult{status: "OK", duration: time.Millisecond}
+	}
+	close(results)
+	r.Run()
+
+	rep := r.Finalize(time.Second)
+	assert.Equal(t, uint64(3), rep.Count)
+	assert.Len(t, rep.Details, 2)
+
+	results = make(chan *callResult, 1)
+	r = newReporter(results, &Options{N: 1}, time.Now())
+	results <- &callResult{status: "OK", duration: time.Millisecond}
+	close(results)
+	r.Run()
+
+	assert.Empty(t, r.Finalize(time.Second).Details)
+}
@@ -3,22 +3,26 @@ package ghz
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // Options represents the request options
@@ -34,8 +38,69 @@ type Options struct {
 	DialTimtout   int                `json:"dialTimeout,omitempty"`
 	KeepaliveTime int                `json:"keepAlice,omitempty"`
 	Data          interface{}        `json:"data,omitempty"`
+	BinaryData    []byte             `json:"-"`
+	DataPath      string             `json:"dataPath,omitempty"`
+	DataMode      string             `json:"dataMode,omitempty"`
+	CSVPath       string             `json:"csvPath,omitempty"`
+	CSVMode       string             `json:"csvMode,omitempty"`
+	CSVEnd        string             `json:"csvEnd,omitempty"`
 	Metadata      *map[string]string `json:"metadata,omitempty"`
 	Insecure      bool               `json:"insecure,omitempty"`
+	OpenLoop      bool               `json:"openLoop,omitempty"`
+	MaxInFlight   int                `json:"maxInFlight,omitempty"`
+	Stages        []Stage            `json:"stages,omitempty"`
+	Calls         []Call             `json:"calls,omitempty"`
+	Steps         []Call             `json:"steps,omitempty"`
+
+	// Random messages generated from the schema of the input type
+	// of every call are sent instead of the data when set
+	Random *RandomOptions `json:"random,omitempty"`
+
+	// Seed of the random values of the template functions and the random
+	// messages, so that runs are reproducible. A different seed is used
+	// every run if 0.
+	Seed int64 `json:"seed,omitempty"`
+
+	// Assertions the outcome of every call is checked against
+	Assertions []Assertion `json:"assertions,omitempty"`
+
+	// The calls are recorded to a file when set
+	Record *RecordOptions `json:"record,omitempty"`
+
+	// Significant figures of the latency percentiles, from 1 to 5.
+	// Defaults to 3, keeping them within 0.1% of the actual latency.
+	Precision int `json:"precision,omitempty"`
+
+	// Maximum number of calls listed in the details of the report.
+	// The calls are not listed if 0.
+	Details int `json:"details,omitempty"`
+
+	// Percentiles of the latency reported, in increasing order.
+	// Defaults to 10, 25, 50, 75, 90, 95 and 99.
+	Percentiles []float64 `json:"percentiles,omitempty"`
+
+	// Layout of the buckets of the latency histogram.
+	// Defaults to 10 linear buckets.
+	Buckets *Buckets `json:"buckets,omitempty"`
+
+	// Length of the intervals of the timeline of the results in the report.
+	// Defaults to 1 second.
+	Interval time.Duration `json:"interval,omitempty"`
+
+	// Called every second with the progress of the run when set
+	OnProgress func(Progress) `json:"-"`
+
+	// Address such as :9090 the live metrics of the run are served on
+	// at /metrics in the Prometheus text format while it runs, when set
+	MetricsAddr string `json:"metricsAddr,omitempty"`
+
+	// How long the metrics are still served once the calls are over, so that
+	// the final values are scraped. Run returns after it.
+	MetricsLinger time.Duration `json:"metricsLinger,omitempty"`
+
+	// Pass / fail conditions checked against the report once the run is over.
+	// The latency percentiles they are on are added to the ones reported.
+	Thresholds []Threshold `json:"thresholds,omitempty"`
 }
 
 // Max size of the buffer of result channel.
@@ -46,53 +111,206 @@ type callResult struct {
 	err      error
 	status   string
 	duration time.Duration
+
+	// the time the call ended
+	end time.Time
+
+	// responseTime is measured from the intended start of the call,
+	// zero when the call was not paced by a rate limit
+	responseTime time.Duration
+
+	// 1-based index of the load stage the call was made in, 0 without stages
+	stage int
+
+	// 1-based index of the call of the scenario or the step of the sequence,
+	// 0 when not known
+	call int
+
+	// names of the assertions the call failed
+	assertions []string
+
+	// the method of the call such as helloworld.Greeter.SayHello,
+	// only set when the metrics are served
+	method string
 }
 
 // Requester is used for doing the requests
 type Requester struct {
 	cc       *grpc.ClientConn
 	stub     grpcdynamic.Stub
-	mtd      *desc.MethodDescriptor
 	reporter *Reporter
 
-	data     string
-	metadata string
+	scenario *scenario
+	steps    []*scenarioCall
+	feeder   *jsonlFeeder
+	csv      *csvFeeder
+	recorder *recorder
 
-	config  *Options
-	results chan *callResult
-	stopCh  chan bool
-	start   time.Time
+	// the template function state of every worker by worker id
+	seed    int64
+	workers sync.Map
+
+	config   *Options
+	results  chan *callResult
+	stopCh   chan bool
+	stopOnce sync.Once
+	start    time.Time
+
+	stages      []Stage
+	stageTotals []time.Duration
 
 	reqCounter int64
+	dropped    uint64
+	inFlight   int64
+
+	// the live metrics, nil when they are not served
+	metrics *metrics
 }
 
 // New creates new Requester
+// If the Calls option is set the calls are made in a weighted mix of the
+// given methods and mtd is not used.
+// If the Steps option is set each request runs the calls of the steps one
+// after another instead, and mtd is not used either.
 func New(mtd *desc.MethodDescriptor, c *Options) (*Requester, error) {
-	md := mtd.GetInputType()
-	payloadMessage := dynamic.NewMessage(md)
-	if payloadMessage == nil {
-		return nil, fmt.Errorf("No input type of method: %s", mtd.GetName())
+	if len(c.Calls) > 0 && len(c.Steps) > 0 {
+		return nil, errors.New("calls and steps cannot be used together")
 	}
 
-	// we need data in string format so
-	// we can do template evaluation on it for every call
-	dataJSON, err := json.Marshal(c.Data)
-	if err != nil {
-		return nil, err
+	var calls []*scenarioCall
+	if len(c.Calls) == 0 && len(c.Steps) == 0 {
+		call, err := newScenarioCall(&Call{Method: mtd, Data: c.Data, BinaryData: c.BinaryData, Metadata: c.Metadata,
+			Assertions: c.Assertions})
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+
+	for i := range c.Calls {
+		call, err := newScenarioCall(&c.Calls[i])
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %v", i+1, err)
+		}
+		calls = append(calls, call)
+	}
+
+	var steps []*scenarioCall
+	names := make(map[string]bool, len(c.Steps))
+	for i := range c.Steps {
+		step, err := newScenarioCall(&c.Steps[i])
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %v", i+1, err)
+		}
+
+		if names[step.name] {
+			return nil, fmt.Errorf("step %d: duplicate name %q", i+1, step.name)
+		}
+		names[step.name] = true
+
+		steps = append(steps, step)
 	}
 
-	// we need metadata in string format so
-	// we can do template evaluation on it for every call
-	mdJSON, err := json.Marshal(c.Metadata)
+	if c.OpenLoop && c.QPS <= 0 {
+		return nil, errors.New("QPS is required for open loop")
+	}
+
+	if c.Precision < 0 || c.Precision > 5 {
+		return nil, errors.New("precision must be between 1 and 5")
+	}
+
+	if c.Details < 0 {
+		return nil, errors.New("details must not be negative")
+	}
+
+	for i, p := range c.Percentiles {
+		if p <= 0 || p > 100 || i > 0 && p <= c.Percentiles[i-1] {
+			return nil, errors.New("percentiles must be above 0, up to 100 and in increasing order")
+		}
+	}
+
+	if c.Interval < 0 {
+		return nil, errors.New("interval must not be negative")
+	}
+
+	if c.Buckets != nil {
+		if err := c.Buckets.validate(); err != nil {
+			return nil, fmt.Errorf("buckets: %v", err)
+		}
+	}
+
+	stages, err := normalizeStages(c.Stages, c.C)
 	if err != nil {
 		return nil, err
 	}
 
 	reqr := &Requester{
 		config:   c,
-		data:     string(dataJSON),
-		metadata: string(mdJSON),
-		mtd:      mtd}
+		scenario: newScenario(calls),
+		steps:    steps,
+		stages:   stages,
+		stopCh:   make(chan bool)}
+
+	if strings.TrimSpace(c.DataPath) != "" {
+		if len(c.Calls) > 0 || len(c.Steps) > 0 {
+			return nil, errors.New("data path cannot be used with calls or steps")
+		}
+
+		if len(c.BinaryData) > 0 {
+			return nil, errors.New("data path cannot be used with binary data")
+		}
+
+		feeder, err := newJSONLFeeder(c.DataPath, c.DataMode)
+		if err != nil {
+			return nil, err
+		}
+
+		reqr.feeder = feeder
+		calls[0].feeder = feeder
+	}
+
+	if strings.TrimSpace(c.CSVPath) != "" {
+		partitions, err := csvPartitions(c, stages)
+		if err != nil {
+			return nil, err
+		}
+
+		csv, err := newCSVFeeder(c.CSVPath, c.CSVMode, c.CSVEnd, partitions)
+		if err != nil {
+			return nil, err
+		}
+
+		reqr.csv = csv
+	}
+
+	if c.Random != nil {
+		if err := c.Random.validate(); err != nil {
+			return nil, err
+		}
+
+		if reqr.feeder != nil || len(c.BinaryData) > 0 {
+			return nil, errors.New("random data cannot be used with a data path or binary data")
+		}
+
+		random := c.Random.withDefaults()
+		for _, call := range calls {
+			call.random = random
+		}
+		for _, step := range reqr.steps {
+			step.random = random
+		}
+	}
+
+	if c.Record != nil {
+		if err := c.Record.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	reqr.seed = c.Seed
+	if reqr.seed == 0 {
+		reqr.seed = time.Now().UnixNano()
+	}
 
 	return reqr, nil
 }
@@ -101,9 +319,12 @@ func New(mtd *desc.MethodDescriptor, c *Options) (*Requester, error) {
 // It blocks until all work is done.
 func (b *Requester) Run() (*Report, error) {
 	b.results = make(chan *callResult, min(b.config.C*1000, maxResult))
-	b.stopCh = make(chan bool, b.config.C)
 	b.start = time.Now()
 
+	if b.config.MetricsAddr != "" {
+		b.metrics = newMetrics(b.config.Buckets)
+	}
+
 	cc, err := b.connect()
 	if err != nil {
 		return nil, err
@@ -112,27 +333,71 @@ func (b *Requester) Run() (*Report, error) {
 	b.cc = cc
 	defer cc.Close()
 
+	if b.metrics != nil {
+		stop, err := b.serveMetrics()
+		if err != nil {
+			return nil, err
+		}
+		defer stop()
+	}
+
+	if b.feeder != nil {
+		defer b.feeder.close()
+	}
+
+	if b.config.Record != nil {
+		b.recorder, err = newRecorder(b.config.Record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	b.stub = grpcdynamic.NewStub(cc)
 
-	b.reporter = newReporter(b.results, b.config)
+	b.reporter = newReporter(b.results, b.config, b.start)
+	b.reporter.metrics = b.metrics
 
 	go func() {
 		b.reporter.Run()
 	}()
 
-	b.runWorkers()
+	var progress sync.WaitGroup
+	progressDone := make(chan struct{})
+	if b.config.OnProgress != nil {
+		progress.Add(1)
+		go func() {
+			defer progress.Done()
+			b.reportProgress(progressDone)
+		}()
+	}
+
+	if len(b.stages) > 0 {
+		b.runStages()
+	} else {
+		b.runWorkers()
+	}
+
+	// no progress is reported once the report is made
+	close(progressDone)
+	progress.Wait()
 
 	report := b.Finish()
 
+	if b.recorder != nil {
+		if err := b.recorder.close(); err != nil {
+			return report, fmt.Errorf("recording calls: %v", err)
+		}
+	}
+
 	return report, nil
 }
 
 // Stop stops the test
 func (b *Requester) Stop() {
-	// Send stop signal so that workers can stop gracefully.
-	for i := 0; i < b.config.C; i++ {
-		b.stopCh <- true
-	}
+	// Close the stop channel so that workers can stop gracefully.
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+	})
 }
 
 // Finish finishes the test run
@@ -143,12 +408,32 @@ func (b *Requester) Finish() *Report {
 	// Wait until the reporter is done.
 	<-b.reporter.done
 
-	return b.reporter.Finalize(total)
+	report := b.reporter.Finalize(total)
+	report.Dropped = atomic.LoadUint64(&b.dropped)
+	if len(b.stageTotals) > 0 {
+		report.Stages = b.reporter.stageReports(b.stages, b.stageTotals)
+	}
+	if len(b.config.Calls) > 0 {
+		report.Calls = b.reporter.callReports(b.scenario.calls, total)
+	}
+	if len(b.steps) > 0 {
+		report.Steps = b.reporter.callReports(b.steps, total)
+	}
+	if len(b.config.Thresholds) > 0 {
+		report.Thresholds = EvaluateThresholds(b.config.Thresholds, report)
+	}
+
+	return report
 }
 
 func (b *Requester) connect() (*grpc.ClientConn, error) {
+	return dial(b.config, grpc.WithStatsHandler(&statsHandler{results: b.results, metrics: b.metrics}))
+}
+
+// dial connects to the host with the TLS and keepalive settings of the options
+func dial(config *Options, extraOpts ...grpc.DialOption) (*grpc.ClientConn, error) {
 	var opts []grpc.DialOption
-	credOptions, err := createClientCredOption(b.config)
+	credOptions, err := createClientCredOption(config)
 	if err != nil {
 		return nil, err
 	}
@@ -156,88 +441,384 @@ func (b *Requester) connect() (*grpc.ClientConn, error) {
 	opts = append(opts, credOptions)
 
 	ctx := context.Background()
-	dialTime := time.Duration(b.config.DialTimtout * int(time.Second))
+	dialTime := time.Duration(config.DialTimtout * int(time.Second))
 	ctx, _ = context.WithTimeout(ctx, dialTime)
 	// cancel is ignored here as connection.Close() is used.
 	// See https://godoc.org/google.golang.org/grpc#DialContext
 
-	if b.config.KeepaliveTime > 0 {
-		timeout := time.Duration(b.config.KeepaliveTime * int(time.Second))
+	if config.KeepaliveTime > 0 {
+		timeout := time.Duration(config.KeepaliveTime * int(time.Second))
 		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:    timeout,
 			Timeout: timeout,
 		}))
 	}
 
-	opts = append(opts, grpc.WithStatsHandler(&statsHandler{b.results}))
+	opts = append(opts, extraOpts...)
 
 	// create client connection
-	return grpc.DialContext(ctx, b.config.Host, opts...)
+	return grpc.DialContext(ctx, config.Host, opts...)
 }
 
 func (b *Requester) runWorkers() {
+	if b.config.OpenLoop {
+		b.runOpenLoop()
+		return
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(b.config.C)
 
 	// Ignore the case where b.N % b.C != 0.
 	for i := 0; i < b.config.C; i++ {
-		go func() {
+		go func(worker int) {
 			defer wg.Done()
 
-			b.runWorker(b.config.N / b.config.C)
-		}()
+			b.runWorker(worker, b.config.N/b.config.C)
+		}(i + 1)
 	}
 	wg.Wait()
 }
 
-func (b *Requester) runWorker(n int) {
+func (b *Requester) runWorker(worker, n int) {
 	var throttle <-chan time.Time
+	var interval time.Duration
 	if b.config.QPS > 0 {
-		throttle = time.Tick(time.Duration(1e6/(b.config.QPS)) * time.Microsecond)
+		interval = time.Duration(1e6/(b.config.QPS)) * time.Microsecond
+		throttle = time.Tick(interval)
 	}
 
+	start := time.Now()
 	for i := 0; i < n; i++ {
 		// Check if application is stopped. Do not send into a closed channel.
 		select {
 		case <-b.stopCh:
 			return
 		default:
+			var intended time.Time
 			if b.config.QPS > 0 {
 				<-throttle
+				// the schedule keeps advancing even if the worker was busy and
+				// missed ticks, so that the wait is included in the response time
+				intended = start.Add(time.Duration(i+1) * interval)
 			}
 
-			b.makeRequest()
+			b.makeRequest(&callInfo{intendedStart: intended, worker: worker})
 		}
 	}
 }
 
-func (b *Requester) makeRequest() {
+// runOpenLoop issues calls from a single scheduler at the aggregate QPS rate
+// regardless of how long in-flight calls take.
+func (b *Requester) runOpenLoop() {
+	maxInFlight := b.config.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = b.config.C
+	}
 
-	reqNum := atomic.AddInt64(&b.reqCounter, 1)
+	var wg sync.WaitGroup
+	b.schedule(&wg, b.config.N, 0, 0,
+		func(time.Duration) float64 { return float64(b.config.QPS) },
+		func(time.Duration) int { return maxInFlight })
+	wg.Wait()
+}
 
-	ctd := newCallTemplateData(b.mtd, reqNum)
+// schedule dispatches up to n calls, each in its own goroutine tracked by wg,
+// at the rate returned by rate for the time elapsed since the start.
+// When the number of calls in flight has reached the cap returned by
+// maxInFlight the dispatch is dropped and counted instead of delayed.
+// It returns early once duration has passed, if it is not zero,
+// or when the test is stopped.
+func (b *Requester) schedule(wg *sync.WaitGroup, n int, duration time.Duration, stage int,
+	rate func(time.Duration) float64, maxInFlight func(time.Duration) int) {
+
+	start := time.Now()
+	intended := start
+	for i := 0; i < n; i++ {
+		elapsed := intended.Sub(start)
+		if duration > 0 && elapsed >= duration {
+			return
+		}
 
-	dataMap, err := ctd.executeData(b.data)
-	if err != nil {
-		return
+		if wait := time.Until(intended); wait > 0 {
+			select {
+			case <-b.stopCh:
+				return
+			case <-time.After(wait):
+			}
+		} else {
+			select {
+			case <-b.stopCh:
+				return
+			default:
+			}
+		}
+
+		if atomic.LoadInt64(&b.inFlight) < int64(maxInFlight(elapsed)) {
+			atomic.AddInt64(&b.inFlight, 1)
+			wg.Add(1)
+			go func(info *callInfo) {
+				defer func() {
+					atomic.AddInt64(&b.inFlight, -1)
+					wg.Done()
+				}()
+
+				b.makeRequest(info)
+			}(&callInfo{intendedStart: intended, stage: stage})
+		} else {
+			atomic.AddUint64(&b.dropped, 1)
+		}
+
+		r := rate(elapsed)
+		if r <= 0 {
+			// the rate ramped down to 0 so no more calls are due
+			return
+		}
+
+		intended = intended.Add(time.Duration(float64(time.Second) / r))
 	}
+}
 
-	mdMap, err := ctd.executeMetadata(b.metadata)
-	if err != nil {
-		return
+// runStages runs the stages of the load profile one after another
+func (b *Requester) runStages() {
+	var wg sync.WaitGroup
+	for i := range b.stages {
+		select {
+		case <-b.stopCh:
+			wg.Wait()
+			return
+		default:
+		}
+
+		stage := &b.stages[i]
+		start := time.Now()
+		if stage.isRateLimited() {
+			b.schedule(&wg, math.MaxInt32, stage.Duration, i+1, stage.rate, stage.concurrency)
+		} else {
+			b.runWorkerPool(&wg, i+1, stage)
+		}
+		b.stageTotals = append(b.stageTotals, time.Since(start))
 	}
+	wg.Wait()
+}
+
+// runWorkerPool runs closed-loop workers for the duration of the stage,
+// starting and stopping workers as the stage concurrency changes
+func (b *Requester) runWorkerPool(wg *sync.WaitGroup, stageNum int, stage *Stage) {
+	var workers []chan bool
+	defer func() {
+		for _, stop := range workers {
+			close(stop)
+		}
+	}()
 
-	var reqMD *metadata.MD
-	if mdMap != nil && len(*mdMap) > 0 {
-		md := metadata.New(*mdMap)
-		reqMD = &md
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	start := time.Now()
+	end := time.After(stage.Duration)
+	for {
+		target := stage.concurrency(time.Since(start))
+		for len(workers) < target {
+			stop := make(chan bool)
+			workers = append(workers, stop)
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+
+				b.runPoolWorker(stop, stageNum, worker)
+			}(len(workers))
+		}
+		for len(workers) > target {
+			close(workers[len(workers)-1])
+			workers = workers[:len(workers)-1]
+		}
+
+		select {
+		case <-b.stopCh:
+			return
+		case <-end:
+			return
+		case <-ticker.C:
+		}
 	}
+}
 
-	input, streamInput, err := createPayloads(dataMap, b.mtd)
-	if err != nil {
+func (b *Requester) runPoolWorker(stop chan bool, stageNum, worker int) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-b.stopCh:
+			return
+		default:
+			b.makeRequest(&callInfo{stage: stageNum, worker: worker})
+		}
+	}
+}
+
+// csvPartitions returns the number of partitions of the rows of the CSV file,
+// one for every worker that can run at once. In partition mode every call
+// has to be made by a worker, so it cannot be used in open-loop mode or with
+// rate limited stages whose calls are issued by a scheduler.
+func csvPartitions(c *Options, stages []Stage) (int, error) {
+	if c.CSVMode != "partition" {
+		return c.C, nil
+	}
+
+	if c.OpenLoop {
+		return 0, errors.New("csv partition mode cannot be used in open-loop mode")
+	}
+
+	if len(stages) == 0 {
+		return c.C, nil
+	}
+
+	var partitions int
+	for i, s := range stages {
+		if s.isRateLimited() {
+			return 0, fmt.Errorf("stage %d: csv partition mode cannot be used with a rate limited stage", i+1)
+		}
+		if s.FromC > partitions {
+			partitions = s.FromC
+		}
+		if s.C > partitions {
+			partitions = s.C
+		}
+	}
+
+	return partitions, nil
+}
+
+func (b *Requester) makeRequest(info *callInfo) {
+	var row map[string]string
+	if b.csv != nil {
+		var err error
+		row, err = b.csv.next(info.worker)
+		if err != nil {
+			// stop the run at the end of the rows
+			b.Stop()
+			return
+		}
+	}
+
+	if len(b.steps) > 0 {
+		b.makeSequence(info, row)
 		return
 	}
 
+	idx := b.scenario.next()
+	info.call = idx + 1
+
+	b.makeCall(b.scenario.calls[idx], info, row, nil)
+}
+
+// makeSequence makes the calls of the steps one after another, making the
+// request and response of every step available to the templates of the
+// following steps. All the steps use the same CSV row.
+// The sequence is aborted when a call fails.
+func (b *Requester) makeSequence(info *callInfo, row map[string]string) {
+	steps := make(map[string]map[string]interface{}, len(b.steps))
+	for i, step := range b.steps {
+		stepInfo := *info
+		stepInfo.call = i + 1
+		if i > 0 {
+			// only the start of the sequence is paced by the rate limit
+			stepInfo.intendedStart = time.Time{}
+		}
+
+		req, res, err := b.makeCall(step, &stepInfo, row, steps)
+		if err != nil {
+			return
+		}
+
+		resMap, err := messageToMap(res)
+		if err != nil {
+			return
+		}
+
+		steps[step.name] = map[string]interface{}{
+			"request":  req,
+			"response": resMap,
+		}
+	}
+}
+
+// makeCall makes a single call and returns the request data and the
+// response message, which is the last message received for server streaming
+func (b *Requester) makeCall(call *scenarioCall, info *callInfo, row map[string]string,
+	steps map[string]map[string]interface{}) (interface{}, proto.Message, error) {
+
+	reqNum := atomic.AddInt64(&b.reqCounter, 1)
+
+	worker := b.templateWorker(info.worker)
+	workerReqNum := atomic.AddInt64(&worker.requests, 1)
+
+	// the template data is only needed if something is evaluated for the call
+	var ctd *callTemplateData
+	if !call.staticData || !call.staticMetadata {
+		ctd = newCallTemplateData(call.mtd, reqNum)
+		ctd.WorkerID = info.worker
+		ctd.WorkerRequestNumber = workerReqNum
+		ctd.Row = row
+		ctd.Steps = steps
+		ctd.worker = worker
+	}
+
+	dataMap, input, streamInput := call.dataMap, call.input, call.streamInput
+	if call.random != nil {
+		var err error
+		input, streamInput, err = createRandomPayloads(call.mtd, call.random, worker.rand)
+		if err != nil {
+			return nil, nil, b.reportRequestError(call, info, err)
+		}
+
+		// the request is only needed by the next steps of a sequence
+		if steps != nil && input != nil {
+			dataMap, _ = messageToMap(input)
+		}
+	} else if !call.staticData {
+		data := call.data
+		if call.feeder != nil {
+			line, err := call.feeder.next()
+			if err != nil {
+				// stop the run once all the data has been used or cannot be read
+				b.Stop()
+				return nil, nil, err
+			}
+			data = parseCallTemplate(line)
+		}
+
+		text, err := data.execute(ctd)
+		if err != nil {
+			return nil, nil, b.reportRequestError(call, info, err)
+		}
+
+		dataMap, err = unmarshalData(text)
+		if err != nil {
+			return nil, nil, b.reportRequestError(call, info, err)
+		}
+
+		input, streamInput, err = createPayloads(dataMap, call.mtd)
+		if err != nil {
+			return nil, nil, b.reportRequestError(call, info, err)
+		}
+	}
+
+	reqMD := call.reqMD
+	if !call.staticMetadata {
+		text, err := call.metadata.execute(ctd)
+		if err != nil {
+			return nil, nil, b.reportRequestError(call, info, err)
+		}
+
+		mdMap, err := unmarshalMetadata(text)
+		if err != nil {
+			return nil, nil, b.reportRequestError(call, info, err)
+		}
+		reqMD = newRequestMetadata(mdMap)
+	}
+
 	ctx := context.Background()
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -251,31 +832,155 @@ func (b *Requester) makeRequest() {
 		ctx = metadata.NewOutgoingContext(ctx, *reqMD)
 	}
 
-	if b.mtd.IsClientStreaming() && b.mtd.IsServerStreaming() {
-		b.makeBidiRequest(&ctx, streamInput)
-	} else if b.mtd.IsClientStreaming() {
-		b.makeClientStreamingRequest(&ctx, streamInput)
-	} else if b.mtd.IsServerStreaming() {
-		b.makeServerStreamingRequest(&ctx, input)
+	ctx = context.WithValue(ctx, callInfoKey{}, info)
+
+	var held *heldResult
+	if len(call.assertions) > 0 {
+		held = &heldResult{}
+		ctx = context.WithValue(ctx, heldResultKey{}, held)
+	}
+
+	// all the messages received are recorded
+	var responses *[]proto.Message
+	var trailer metadata.MD
+	var opts []grpc.CallOption
+	if b.recorder != nil {
+		responses = &[]proto.Message{}
+		opts = append(opts, grpc.Trailer(&trailer))
+	}
+
+	start := time.Now()
+
+	var res proto.Message
+	var err error
+	messages := 1
+	mtd := call.mtd
+	if mtd.IsClientStreaming() && mtd.IsServerStreaming() {
+		res, messages, err = b.makeBidiRequest(&ctx, mtd, streamInput, responses, opts...)
+	} else if mtd.IsClientStreaming() {
+		res, err = b.makeClientStreamingRequest(&ctx, mtd, streamInput, opts...)
+	} else if mtd.IsServerStreaming() {
+		res, messages, err = b.makeServerStreamingRequest(&ctx, mtd, input, responses, opts...)
 	} else {
-		b.stub.InvokeRpc(ctx, b.mtd, input)
+		res, err = b.stub.InvokeRpc(ctx, mtd, input, opts...)
+	}
+
+	latency := time.Since(start)
+	rpcErr := err
+
+	if held != nil {
+		err = b.reportAssertions(call, held, res, messages, err)
+	}
+
+	if b.recorder != nil {
+		if !mtd.IsServerStreaming() && res != nil {
+			*responses = append(*responses, res)
+		}
+
+		b.recorder.record(&recordedCall{
+			start:       start,
+			latency:     latency,
+			mtd:         mtd,
+			input:       input,
+			streamInput: streamInput,
+			metadata:    reqMD,
+			responses:   *responses,
+			trailer:     trailer,
+			rpcErr:      rpcErr,
+			err:         err,
+		})
+	}
+
+	return dataMap, res, err
+}
+
+// reportRequestError reports a call whose request could not be built, such as
+// when its template fails to execute, as failed with InvalidArgument without
+// making it, so that it is counted like the calls that were made.
+// It returns the error of the call.
+func (b *Requester) reportRequestError(call *scenarioCall, info *callInfo, err error) error {
+	end := time.Now()
+	res := &callResult{
+		err:    status.Error(codes.InvalidArgument, err.Error()),
+		status: codes.InvalidArgument.String(),
+		end:    end,
+		stage:  info.stage,
+		call:   info.call,
+	}
+	if !info.intendedStart.IsZero() {
+		res.responseTime = end.Sub(info.intendedStart)
+	}
+	if b.metrics != nil {
+		res.method = call.mtd.GetFullyQualifiedName()
 	}
+
+	b.results <- res
+	return res.err
+}
+
+// reportAssertions checks the outcome of the call against its assertions and
+// reports the result held back from the stats handler. It returns the error
+// of the call, nil if the call ended with an expected status.
+func (b *Requester) reportAssertions(call *scenarioCall, held *heldResult, res proto.Message,
+	messages int, err error) error {
+
+	result := held.release()
+	if result == nil {
+		// the call has not ended, and is reported by the stats handler as is
+		return err
+	}
+
+	failed, expected := checkAssertions(call.assertions, &callOutcome{
+		status:   result.status,
+		response: res,
+		messages: messages,
+	})
+
+	if len(failed) > 0 {
+		result.err = fmt.Errorf("assertion failed: %s", strings.Join(failed, "; "))
+		result.assertions = failed
+	} else if expected {
+		result.err = nil
+	}
+
+	b.results <- result
+	return result.err
 }
 
-func (b *Requester) makeClientStreamingRequest(ctx *context.Context, input *[]*dynamic.Message) {
-	str, err := b.stub.InvokeRpcClientStream(*ctx, b.mtd)
+// templateWorker returns the template function state of the worker
+func (b *Requester) templateWorker(worker int) *templateWorker {
+	if w, ok := b.workers.Load(worker); ok {
+		return w.(*templateWorker)
+	}
+
+	w, _ := b.workers.LoadOrStore(worker, newTemplateWorker(worker, b.seed))
+	return w.(*templateWorker)
+}
+
+// newRequestMetadata returns the metadata to send, nil if there is none
+func newRequestMetadata(mdMap *map[string]string) *metadata.MD {
+	if mdMap == nil || len(*mdMap) == 0 {
+		return nil
+	}
+
+	md := metadata.New(*mdMap)
+	return &md
+}
+
+func (b *Requester) makeClientStreamingRequest(ctx *context.Context, mtd *desc.MethodDescriptor, input *[]*dynamic.Message,
+	opts ...grpc.CallOption) (proto.Message, error) {
+
+	str, err := b.stub.InvokeRpcClientStream(*ctx, mtd, opts...)
 	counter := 0
 	for err == nil {
 		streamInput := *input
 		inputLen := len(streamInput)
 		if input == nil || inputLen == 0 {
-			str.CloseAndReceive()
-			break
+			return str.CloseAndReceive()
 		}
 
 		if counter == inputLen {
-			str.CloseAndReceive()
-			break
+			return str.CloseAndReceive()
 		}
 
 		payload := streamInput[counter]
@@ -283,28 +988,28 @@ func (b *Requester) makeClientStreamingRequest(ctx *context.Context, input *[]*d
 		if err == io.EOF {
 			// We get EOF on send if the server says "go away"
 			// We have to use CloseAndReceive to get the actual code
-			str.CloseAndReceive()
-			break
+			return str.CloseAndReceive()
 		}
 		counter++
 	}
+	return nil, err
 }
 
-func (b *Requester) makeServerStreamingRequest(ctx *context.Context, input *dynamic.Message) {
-	str, err := b.stub.InvokeRpcServerStream(*ctx, b.mtd, input)
-	for err == nil {
-		_, err := str.RecvMsg()
-		if err != nil {
-			if err == io.EOF {
-				err = nil
-			}
-			break
-		}
+func (b *Requester) makeServerStreamingRequest(ctx *context.Context, mtd *desc.MethodDescriptor, input *dynamic.Message,
+	responses *[]proto.Message, opts ...grpc.CallOption) (proto.Message, int, error) {
+
+	str, err := b.stub.InvokeRpcServerStream(*ctx, mtd, input, opts...)
+	if err != nil {
+		return nil, 0, err
 	}
+
+	return recvAll(str.RecvMsg, responses)
 }
 
-func (b *Requester) makeBidiRequest(ctx *context.Context, input *[]*dynamic.Message) {
-	str, err := b.stub.InvokeRpcBidiStream(*ctx, b.mtd)
+func (b *Requester) makeBidiRequest(ctx *context.Context, mtd *desc.MethodDescriptor, input *[]*dynamic.Message,
+	responses *[]proto.Message, opts ...grpc.CallOption) (proto.Message, int, error) {
+
+	str, err := b.stub.InvokeRpcBidiStream(*ctx, mtd, opts...)
 	counter := 0
 	for err == nil {
 		streamInput := *input
@@ -324,13 +1029,31 @@ func (b *Requester) makeBidiRequest(ctx *context.Context, input *[]*dynamic.Mess
 		counter++
 	}
 
-	for err == nil {
-		_, err := str.RecvMsg()
+	if err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+
+	return recvAll(str.RecvMsg, responses)
+}
+
+// recvAll receives the messages of a stream until it ends and returns the last
+// message received and the number of messages. All the messages are appended
+// to responses if it is not nil.
+func recvAll(recv func() (proto.Message, error), responses *[]proto.Message) (proto.Message, int, error) {
+	var last proto.Message
+	count := 0
+	for {
+		msg, err := recv()
 		if err != nil {
 			if err == io.EOF {
-				err = nil
+				return last, count, nil
 			}
-			break
+			return last, count, err
+		}
+		last = msg
+		count++
+		if responses != nil {
+			*responses = append(*responses, msg)
 		}
 	}
 }
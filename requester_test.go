@@ -1,17 +1,23 @@
 package ghz
 
 import (
+	"io/ioutil"
 	"net"
+	"os"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/tab1293/ghz/internal/helloworld"
 	"github.com/tab1293/ghz/protodesc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
 )
 
 const port = ":50051"
@@ -37,7 +43,7 @@ func startServer(secure bool) (*helloworld.Greeter, *grpc.Server, error) {
 
 	gs := helloworld.NewGreeter()
 	helloworld.RegisterGreeterServer(s, gs)
-	// reflection.Register(s)
+	reflection.Register(s)
 	go func() {
 		s.Serve(lis)
 	}()
@@ -140,6 +146,779 @@ func TestRequesterUnary(t *testing.T) {
 	})
 }
 
+func TestRequesterOpenLoop(t *testing.T) {
+	callType := helloworld.Unary
+
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+
+	data := make(map[string]interface{})
+	data["name"] = "bob"
+
+	t.Run("requires QPS", func(t *testing.T) {
+		reqr, err := New(md, &Options{
+			Host:     localhost,
+			N:        10,
+			C:        2,
+			OpenLoop: true,
+			Data:     data,
+			Insecure: true,
+		})
+		assert.Error(t, err)
+		assert.Nil(t, reqr)
+	})
+
+	t.Run("aggregate rate", func(t *testing.T) {
+		gs.ResetCounters()
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			QPS:         20,
+			OpenLoop:    true,
+			Timeout:     20,
+			DialTimtout: 20,
+			Data:        data,
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Equal(t, 10, int(report.Count))
+		assert.Equal(t, 0, int(report.Dropped))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.True(t, report.Total >= 450*time.Millisecond)
+		assert.True(t, report.Total < 2*time.Second)
+		assert.NotNil(t, report.ResponseTime)
+		assert.True(t, report.ResponseTime.Slowest >= report.Fastest)
+
+		count := gs.GetCount(callType)
+		assert.Equal(t, 10, count)
+	})
+}
+
+func TestRequesterStages(t *testing.T) {
+	callType := helloworld.Unary
+
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+
+	data := make(map[string]interface{})
+	data["name"] = "bob"
+
+	gs.ResetCounters()
+
+	reqr, err := New(md, &Options{
+		Host:        localhost,
+		N:           200,
+		C:           2,
+		Timeout:     20,
+		DialTimtout: 20,
+		Data:        data,
+		Insecure:    true,
+		Stages: []Stage{
+			{Duration: 500 * time.Millisecond, FromQPS: 10, QPS: 30},
+			{Duration: 300 * time.Millisecond, C: 1},
+		},
+	})
+	assert.NoError(t, err)
+
+	report, err := reqr.Run()
+	assert.NoError(t, err)
+	assert.NotNil(t, report)
+	assert.Len(t, report.ErrorDist, 0)
+	assert.Len(t, report.Stages, 2)
+
+	rateStage := report.Stages[0]
+	assert.Equal(t, 10, rateStage.Stage.FromQPS)
+	assert.Equal(t, 30, rateStage.Stage.QPS)
+	assert.True(t, rateStage.Count >= 8 && rateStage.Count <= 12, "count: %d", rateStage.Count)
+
+	workerStage := report.Stages[1]
+	assert.Equal(t, 0, workerStage.Stage.QPS)
+	assert.True(t, workerStage.Count > 0)
+
+	assert.Equal(t, rateStage.Count+workerStage.Count, report.Count)
+
+	count := gs.GetCount(callType)
+	assert.Equal(t, int(report.Count), count)
+}
+
+func TestRequesterScenario(t *testing.T) {
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	unary, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+
+	stream, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHellos", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+
+	bidi, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHelloBidi", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+
+	data := make(map[string]interface{})
+	data["name"] = "bob"
+
+	t.Run("requires method", func(t *testing.T) {
+		reqr, err := New(nil, &Options{
+			Host:     localhost,
+			N:        10,
+			C:        2,
+			Calls:    []Call{{Data: data}},
+			Insecure: true,
+		})
+		assert.Error(t, err)
+		assert.Nil(t, reqr)
+	})
+
+	t.Run("weighted mix", func(t *testing.T) {
+		gs.ResetCounters()
+
+		reqr, err := New(nil, &Options{
+			Host:        localhost,
+			N:           20,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Insecure:    true,
+			Calls: []Call{
+				{Method: unary, Weight: 5, Data: data},
+				{Method: stream, Weight: 3, Data: data},
+				{Name: "bidi", Method: bidi, Weight: 2, Data: []interface{}{data, data}},
+			},
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Equal(t, 20, int(report.Count))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Len(t, report.Calls, 3)
+
+		assert.Equal(t, "helloworld.Greeter.SayHello", report.Calls[0].Name)
+		assert.Equal(t, "helloworld.Greeter.SayHello", report.Calls[0].Method)
+		assert.Equal(t, 5, report.Calls[0].Weight)
+		assert.Equal(t, 10, int(report.Calls[0].Count))
+
+		assert.Equal(t, "helloworld.Greeter.SayHellos", report.Calls[1].Name)
+		assert.Equal(t, 6, int(report.Calls[1].Count))
+
+		assert.Equal(t, "bidi", report.Calls[2].Name)
+		assert.Equal(t, "helloworld.Greeter.SayHelloBidi", report.Calls[2].Method)
+		assert.Equal(t, 4, int(report.Calls[2].Count))
+
+		assert.Equal(t, 10, gs.GetCount(helloworld.Unary))
+		assert.Equal(t, 6, gs.GetCount(helloworld.ServerStream))
+		assert.Equal(t, 4, gs.GetCount(helloworld.Bidi))
+	})
+}
+
+func TestRequesterSteps(t *testing.T) {
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	unary, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+
+	stream, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHellos", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+
+	t.Run("duplicate names", func(t *testing.T) {
+		reqr, err := New(nil, &Options{
+			Host:     localhost,
+			N:        10,
+			C:        2,
+			Insecure: true,
+			Steps: []Call{
+				{Method: unary, Data: map[string]interface{}{"name": "bob"}},
+				{Method: unary, Data: map[string]interface{}{"name": "bob"}},
+			},
+		})
+		assert.Error(t, err)
+		assert.Nil(t, reqr)
+	})
+
+	t.Run("responses are used in the following steps", func(t *testing.T) {
+		gs.ResetCounters()
+
+		// the last step is only valid if the second one got the response of the first
+		reqr, err := New(nil, &Options{
+			Host:        localhost,
+			N:           4,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Insecure:    true,
+			Steps: []Call{
+				{Name: "first", Method: unary, Data: map[string]interface{}{"name": "bob"}},
+				{Name: "second", Method: unary, Data: map[string]interface{}{"name": "{{ .Steps.first.response.message }}"}},
+				{Name: "third", Method: stream, Data: map[string]interface{}{
+					"{{ if eq .Steps.second.response.message `Hello Hello bob` }}name{{ else }}unknown{{ end }}": "kate",
+				}},
+			},
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+		assert.Equal(t, 12, int(report.Count))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Len(t, report.Steps, 3)
+		assert.Equal(t, "first", report.Steps[0].Name)
+		assert.Equal(t, "helloworld.Greeter.SayHello", report.Steps[0].Method)
+		assert.Equal(t, 4, int(report.Steps[0].Count))
+		assert.Equal(t, "third", report.Steps[2].Name)
+		assert.Equal(t, 4, int(report.Steps[2].Count))
+
+		assert.Equal(t, 8, gs.GetCount(helloworld.Unary))
+		assert.Equal(t, 4, gs.GetCount(helloworld.ServerStream))
+	})
+
+	t.Run("sequence is aborted on failure", func(t *testing.T) {
+		gs.ResetCounters()
+
+		reqr, err := New(nil, &Options{
+			Host:        localhost,
+			N:           4,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Insecure:    true,
+			Steps: []Call{
+				{Name: "first", Method: unary, Data: map[string]interface{}{"unknown": "bob"}},
+				{Name: "second", Method: unary, Data: map[string]interface{}{"name": "bob"}},
+			},
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.NotNil(t, report)
+
+		// the request of the first step cannot be built, which is counted as a failed call
+		assert.Equal(t, 4, int(report.Count))
+		assert.Len(t, report.ErrorDist, 1)
+		assert.Equal(t, 4, int(report.Steps[0].Count))
+		assert.Equal(t, 0, int(report.Steps[1].Count))
+		assert.Equal(t, 0, gs.GetCount(helloworld.Unary))
+	})
+}
+
+func TestRequesterDataPath(t *testing.T) {
+	callType := helloworld.Unary
+
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+
+	t.Run("round robin", func(t *testing.T) {
+		gs.ResetCounters()
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			DataPath:    "./testdata/data.jsonl",
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, int(report.Count))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Equal(t, 10, gs.GetCount(callType))
+	})
+
+	t.Run("once", func(t *testing.T) {
+		gs.ResetCounters()
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			DataPath:    "./testdata/data.jsonl",
+			DataMode:    "once",
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, int(report.Count))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Equal(t, 3, gs.GetCount(callType))
+	})
+}
+
+func TestRequesterBinaryData(t *testing.T) {
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	marshal := func(md *desc.MethodDescriptor, name string) []byte {
+		msg := dynamic.NewMessage(md.GetInputType())
+		msg.SetFieldByName("name", name)
+		b, err := msg.Marshal()
+		assert.NoError(t, err)
+		return b
+	}
+
+	t.Run("unary", func(t *testing.T) {
+		gs.ResetCounters()
+
+		md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			BinaryData:  marshal(md, "bob"),
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, int(report.Count))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Equal(t, 10, gs.GetCount(helloworld.Unary))
+	})
+
+	t.Run("client streaming", func(t *testing.T) {
+		gs.ResetCounters()
+
+		md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHelloCS", "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		var data []byte
+		for _, name := range []string{"bob", "kate", "foo"} {
+			b := marshal(md, name)
+			data = append(data, proto.EncodeVarint(uint64(len(b)))...)
+			data = append(data, b...)
+		}
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			BinaryData:  data,
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, int(report.Count))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Equal(t, 10, gs.GetCount(helloworld.ClientStream))
+	})
+
+	t.Run("fail for invalid data", func(t *testing.T) {
+		md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		_, err = New(md, &Options{
+			Host:       localhost,
+			N:          10,
+			C:          2,
+			BinaryData: []byte{0x0a, 0x05, 'b'},
+			Insecure:   true,
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestRequesterRandom(t *testing.T) {
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	t.Run("unary", func(t *testing.T) {
+		gs.ResetCounters()
+
+		md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Random:      &RandomOptions{Length: 1000},
+			Seed:        42,
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, int(report.Count))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Equal(t, 10, gs.GetCount(helloworld.Unary))
+	})
+
+	t.Run("client streaming", func(t *testing.T) {
+		gs.ResetCounters()
+
+		md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHelloCS", "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Random:      &RandomOptions{},
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, int(report.Count))
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Equal(t, 10, gs.GetCount(helloworld.ClientStream))
+	})
+
+	t.Run("fail with binary data", func(t *testing.T) {
+		md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		_, err = New(md, &Options{
+			Host:       localhost,
+			N:          10,
+			C:          2,
+			BinaryData: []byte{0x0a, 0x03, 'b', 'o', 'b'},
+			Random:     &RandomOptions{},
+			Insecure:   true,
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestRequesterAssertions(t *testing.T) {
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	bob := map[string]interface{}{"name": "bob"}
+
+	run := func(call string, data interface{}, assertions []Assertion) *Report {
+		md, err := protodesc.GetMethodDescFromProtos(call, []string{"./testdata/greeter.proto", "./testdata/multi"}, []string{})
+		assert.NoError(t, err)
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Data:        data,
+			Insecure:    true,
+			Assertions:  assertions,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, int(report.Count))
+		return report
+	}
+
+	t.Run("pass", func(t *testing.T) {
+		gs.ResetCounters()
+
+		report := run("helloworld.Greeter.SayHello", bob, []Assertion{
+			{Status: []string{"OK"}},
+			{Path: "$.message", Equals: "Hello bob"},
+		})
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Len(t, report.AssertionDist, 0)
+		assert.Equal(t, 10, report.StatusCodeDist["OK"])
+		assert.Len(t, report.LatencyDistribution, 7)
+		assert.Equal(t, 10, gs.GetCount(helloworld.Unary))
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		report := run("helloworld.Greeter.SayHello", bob, []Assertion{
+			{Status: []string{"OK"}},
+			{Name: "joe", Path: "$.message", Equals: "Hello joe"},
+			{Path: "$.message", Matches: "^Hi"},
+		})
+		assert.Equal(t, map[string]int{`assertion failed: joe; $.message =~ "^Hi"`: 10}, report.ErrorDist)
+		assert.Equal(t, map[string]int{"joe": 10, `$.message =~ "^Hi"`: 10}, report.AssertionDist)
+		assert.Len(t, report.StatusCodeDist, 0)
+	})
+
+	t.Run("server stream messages", func(t *testing.T) {
+		four, three := 4, 3
+
+		report := run("helloworld.Greeter.SayHellos", bob, []Assertion{{Messages: &four}})
+		assert.Len(t, report.ErrorDist, 0)
+
+		report = run("helloworld.Greeter.SayHellos", bob, []Assertion{{Messages: &three}})
+		assert.Equal(t, map[string]int{"messages == 3": 10}, report.AssertionDist)
+	})
+
+	t.Run("expected status", func(t *testing.T) {
+		// the test server does not implement the service
+		report := run("multi.Echo.Echo", map[string]interface{}{"message": "bob"}, []Assertion{{Status: []string{"Unimplemented"}}})
+		assert.Len(t, report.ErrorDist, 0)
+		assert.Equal(t, map[string]int{"Unimplemented": 10}, report.StatusCodeDist)
+
+		report = run("multi.Echo.Echo", map[string]interface{}{"message": "bob"}, []Assertion{{Status: []string{"OK"}}})
+		assert.Len(t, report.ErrorDist, 1)
+		assert.Equal(t, map[string]int{"status in [OK]": 10}, report.AssertionDist)
+	})
+
+	t.Run("steps", func(t *testing.T) {
+		md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		reqr, err := New(nil, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Insecure:    true,
+			Steps: []Call{
+				{Name: "first", Method: md, Data: map[string]interface{}{"name": "bob"},
+					Assertions: []Assertion{{Path: "$.message", Equals: "Hello joe"}}},
+				{Name: "second", Method: md, Data: map[string]interface{}{"name": "kate"}},
+			},
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+
+		// the sequence is aborted when an assertion fails
+		assert.Equal(t, 10, int(report.Count))
+		assert.Equal(t, map[string]int{`$.message == "Hello joe"`: 10}, report.Steps[0].AssertionDist)
+		assert.Equal(t, 0, int(report.Steps[1].Count))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+		assert.NoError(t, err)
+
+		_, err = New(md, &Options{
+			Host:       localhost,
+			Data:       map[string]interface{}{"name": "bob"},
+			Assertions: []Assertion{{Status: []string{"Fine"}}},
+		})
+		assert.EqualError(t, err, `assertion 1: unknown status code "Fine"`)
+	})
+}
+
+func TestRequesterCSV(t *testing.T) {
+	callType := helloworld.Unary
+
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+
+	t.Run("rows are used in templates", func(t *testing.T) {
+		gs.ResetCounters()
+
+		// the call is only valid if the row was used for the field name
+		data := map[string]interface{}{"{{ .Row.field }}": "bob"}
+
+		file, err := ioutil.TempFile("", "ghz_csv")
+		assert.NoError(t, err)
+		file.WriteString("field\nname\n")
+		file.Close()
+		defer os.Remove(file.Name())
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           10,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Data:        data,
+			CSVPath:     file.Name(),
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, int(report.Count))
+		assert.Equal(t, 10, gs.GetCount(callType))
+	})
+
+	t.Run("stop at the end of the rows", func(t *testing.T) {
+		gs.ResetCounters()
+
+		reqr, err := New(md, &Options{
+			Host:        localhost,
+			N:           20,
+			C:           2,
+			Timeout:     20,
+			DialTimtout: 20,
+			Data:        map[string]interface{}{"name": "{{ .Row.name }}"},
+			CSVPath:     "./testdata/users.csv",
+			CSVMode:     "partition",
+			CSVEnd:      "stop",
+			Insecure:    true,
+		})
+		assert.NoError(t, err)
+
+		report, err := reqr.Run()
+		assert.NoError(t, err)
+		assert.True(t, report.Count >= 4 && report.Count <= 5, "count: %d", report.Count)
+		assert.Equal(t, int(report.Count), gs.GetCount(callType))
+	})
+
+	t.Run("partition mode needs a worker for every call", func(t *testing.T) {
+		var tests = []struct {
+			name     string
+			opts     Options
+			expected string
+		}{
+			{"open loop", Options{C: 2, QPS: 10, OpenLoop: true},
+				"csv partition mode cannot be used in open-loop mode"},
+			{"rate limited stage", Options{C: 2, Stages: []Stage{{Duration: time.Second, C: 2}, {Duration: time.Second, QPS: 10}}},
+				"stage 2: csv partition mode cannot be used with a rate limited stage"},
+			{"more workers than rows", Options{C: 2, Stages: []Stage{{Duration: time.Second, FromC: 1, C: 6}}},
+				"5 rows in ./testdata/users.csv are not enough for 6 partitions"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				opts := tt.opts
+				opts.Host = localhost
+				opts.CSVPath = "./testdata/users.csv"
+				opts.CSVMode = "partition"
+
+				_, err := New(md, &opts)
+				assert.EqualError(t, err, tt.expected)
+			})
+		}
+
+		// the partitions follow the concurrency of the stages rather than -c
+		reqr, err := New(md, &Options{
+			Host:    localhost,
+			C:       50,
+			CSVPath: "./testdata/users.csv",
+			CSVMode: "partition",
+			Stages:  []Stage{{Duration: time.Second, FromC: 1, C: 5}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, reqr.csv.partPos, 5)
+	})
+}
+
+func TestRequesterTemplateError(t *testing.T) {
+	callType := helloworld.Unary
+
+	gs, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+
+	gs.ResetCounters()
+
+	reqr, err := New(md, &Options{
+		Host:        localhost,
+		N:           4,
+		C:           1,
+		Timeout:     20,
+		DialTimtout: 20,
+		// a raw string as the quotes of an argument would be escaped in the JSON of the data
+		Data:     map[string]interface{}{"name": "{{ base64Decode `!!` }}"},
+		Insecure: true,
+	})
+	assert.NoError(t, err)
+
+	report, err := reqr.Run()
+	assert.NoError(t, err)
+
+	// the calls are not made but counted as failed
+	assert.Equal(t, 4, int(report.Count))
+	assert.Equal(t, 0, gs.GetCount(callType))
+	assert.Len(t, report.ErrorDist, 1)
+	for msg, count := range report.ErrorDist {
+		assert.Equal(t, 4, count)
+		assert.Contains(t, msg, "code = InvalidArgument")
+		assert.Contains(t, msg, "base64Decode")
+	}
+	assert.Equal(t, uint64(4), report.StatusLatency["InvalidArgument"].Count)
+}
+
 func TestRequesterServerStreaming(t *testing.T) {
 	callType := helloworld.ServerStream
 
@@ -312,3 +1091,74 @@ func TestRequesterUnarySecure(t *testing.T) {
 	count := gs.GetCount(callType)
 	assert.Equal(t, 18, count)
 }
+
+func BenchmarkRequesterUnary(b *testing.B) {
+	_, s, err := startServer(false)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer s.Stop()
+
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	msg := dynamic.NewMessage(md.GetInputType())
+	msg.SetFieldByName("name", "bob")
+	binData, err := msg.Marshal()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	benchmarks := []struct {
+		name    string
+		options Options
+	}{
+		{"static data", Options{
+			Data:     map[string]interface{}{"name": "bob"},
+			Metadata: &map[string]string{"user-id": "1"},
+		}},
+		{"template data", Options{
+			Data:     map[string]interface{}{"name": "{{.RequestNumber}}"},
+			Metadata: &map[string]string{"user-id": "1"},
+		}},
+		{"template metadata", Options{
+			Data:     map[string]interface{}{"name": "bob"},
+			Metadata: &map[string]string{"request-id": "{{.RequestNumber}}"},
+		}},
+		{"binary data", Options{
+			BinaryData: binData,
+		}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			o := bm.options
+			o.Host = localhost
+			o.N = b.N
+			o.C = 1
+			o.Timeout = 20
+			o.DialTimtout = 20
+			o.Insecure = true
+
+			reqr, err := New(md, &o)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			report, err := reqr.Run()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			if len(report.ErrorDist) > 0 {
+				b.Fatal(report.ErrorDist)
+			}
+		})
+	}
+}
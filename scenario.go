@@ -0,0 +1,191 @@
+package ghz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc/metadata"
+)
+
+// Call is one of the calls of a weighted traffic mix
+type Call struct {
+	// Name of the call in the report. Defaults to the fully qualified method name.
+	Name string `json:"name,omitempty"`
+
+	// The method to call
+	Method *desc.MethodDescriptor `json:"-"`
+
+	// The relative weight of the call in the mix. Defaults to 1.
+	Weight int `json:"weight,omitempty"`
+
+	Data     interface{}        `json:"data,omitempty"`
+	Metadata *map[string]string `json:"metadata,omitempty"`
+
+	// The request as serialized protobuf, used instead of Data when set.
+	// For client streaming it is a stream of length-delimited messages.
+	BinaryData []byte `json:"-"`
+
+	// Assertions the outcome of every call is checked against
+	Assertions []Assertion `json:"assertions,omitempty"`
+}
+
+// MarshalJSON is our custom implementation to include the method name
+func (c Call) MarshalJSON() ([]byte, error) {
+	type Alias Call
+	var method string
+	if c.Method != nil {
+		method = c.Method.GetFullyQualifiedName()
+	}
+
+	return json.Marshal(&struct {
+		Method string `json:"call"`
+		*Alias
+	}{
+		Method: method,
+		Alias:  (*Alias)(&c),
+	})
+}
+
+// scenarioCall is a call of the mix or a step of the sequence
+// prepared for the requester
+type scenarioCall struct {
+	name   string
+	mtd    *desc.MethodDescriptor
+	weight int
+
+	// data and metadata parsed once so that only
+	// the templates are evaluated for every call
+	data     *callTemplate
+	metadata *callTemplate
+
+	// streams the data of every call instead of data when set
+	feeder *jsonlFeeder
+
+	// the payloads built once when the data is binary or has no template
+	// actions, used for every call instead of data
+	staticData  bool
+	dataMap     interface{}
+	input       *dynamic.Message
+	streamInput *[]*dynamic.Message
+
+	// generates the payloads of every call instead of data when set
+	random *RandomOptions
+
+	// the metadata built once when it has no template actions
+	staticMetadata bool
+	reqMD          *metadata.MD
+
+	assertions []*assertion
+
+	// current weight for the smooth weighted round robin selection
+	current int
+}
+
+// newScenarioCall validates the call and prepares it for the requester
+func newScenarioCall(c *Call) (*scenarioCall, error) {
+	if c.Method == nil {
+		return nil, errors.New("method is required")
+	}
+
+	if dynamic.NewMessage(c.Method.GetInputType()) == nil {
+		return nil, fmt.Errorf("No input type of method: %s", c.Method.GetName())
+	}
+
+	if c.Weight < 0 {
+		return nil, errors.New("weight must not be negative")
+	}
+
+	dataJSON, err := json.Marshal(c.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	mdJSON, err := json.Marshal(c.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &scenarioCall{
+		name:     c.Name,
+		mtd:      c.Method,
+		weight:   c.Weight,
+		data:     parseCallTemplate(string(dataJSON)),
+		metadata: parseCallTemplate(string(mdJSON))}
+
+	sc.assertions, err = newAssertions(c.Assertions)
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.name == "" {
+		sc.name = c.Method.GetFullyQualifiedName()
+	}
+
+	if sc.weight == 0 {
+		sc.weight = 1
+	}
+
+	if len(c.BinaryData) > 0 {
+		sc.input, sc.streamInput, err = createBinaryPayloads(c.BinaryData, c.Method)
+		if err != nil {
+			return nil, err
+		}
+		sc.staticData = true
+	} else if c.Data != nil && sc.data.isStatic() {
+		// invalid data is left to fail every call, as templated data would
+		dataMap, err := unmarshalData([]byte(sc.data.text))
+		if err == nil {
+			sc.input, sc.streamInput, err = createPayloads(dataMap, c.Method)
+		}
+		sc.dataMap, sc.staticData = dataMap, err == nil
+	}
+
+	if sc.metadata.isStatic() {
+		mdMap, err := unmarshalMetadata([]byte(sc.metadata.text))
+		sc.reqMD, sc.staticMetadata = newRequestMetadata(mdMap), err == nil
+	}
+
+	return sc, nil
+}
+
+// scenario picks the calls of the mix in proportion to their weights
+type scenario struct {
+	mu    sync.Mutex
+	calls []*scenarioCall
+	total int
+}
+
+func newScenario(calls []*scenarioCall) *scenario {
+	s := &scenario{calls: calls}
+	for _, c := range calls {
+		s.total += c.weight
+	}
+	return s
+}
+
+// next returns the 0-based index of the next call to make.
+// It uses smooth weighted round robin so that the calls are interleaved
+// evenly rather than made in bursts of the same method.
+func (s *scenario) next() int {
+	if len(s.calls) == 1 {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	best := 0
+	for i, c := range s.calls {
+		c.current += c.weight
+		if c.current > s.calls[best].current {
+			best = i
+		}
+	}
+	s.calls[best].current -= s.total
+
+	return best
+}
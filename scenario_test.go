@@ -0,0 +1,88 @@
+package ghz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz/protodesc"
+)
+
+func TestScenario_next(t *testing.T) {
+	t.Run("single call", func(t *testing.T) {
+		s := newScenario([]*scenarioCall{{name: "a", weight: 1}})
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, 0, s.next())
+		}
+	})
+
+	t.Run("weighted", func(t *testing.T) {
+		s := newScenario([]*scenarioCall{
+			{name: "a", weight: 7},
+			{name: "b", weight: 2},
+			{name: "c", weight: 1},
+		})
+
+		counts := make([]int, 3)
+		for i := 0; i < 1000; i++ {
+			counts[s.next()]++
+		}
+		assert.Equal(t, []int{700, 200, 100}, counts)
+	})
+
+	t.Run("interleaved", func(t *testing.T) {
+		s := newScenario([]*scenarioCall{
+			{name: "a", weight: 2},
+			{name: "b", weight: 1},
+		})
+
+		var order []int
+		for i := 0; i < 6; i++ {
+			order = append(order, s.next())
+		}
+		assert.Equal(t, []int{0, 1, 0, 0, 1, 0}, order)
+	})
+}
+
+func TestScenario_newScenarioCall(t *testing.T) {
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+
+	t.Run("static data and metadata are built once", func(t *testing.T) {
+		sc, err := newScenarioCall(&Call{
+			Method:   md,
+			Data:     map[string]interface{}{"name": "bob"},
+			Metadata: &map[string]string{"user-id": "1"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "helloworld.Greeter.SayHello", sc.name)
+		assert.Equal(t, 1, sc.weight)
+		assert.True(t, sc.staticData)
+		assert.Equal(t, "bob", sc.input.GetFieldByName("name"))
+		assert.True(t, sc.staticMetadata)
+		assert.Equal(t, []string{"1"}, (*sc.reqMD)["user-id"])
+	})
+
+	t.Run("templates are evaluated for every call", func(t *testing.T) {
+		sc, err := newScenarioCall(&Call{
+			Method:   md,
+			Data:     map[string]interface{}{"name": "{{.RequestNumber}}"},
+			Metadata: &map[string]string{"request-id": "{{.RequestNumber}}"},
+		})
+		assert.NoError(t, err)
+		assert.False(t, sc.staticData)
+		assert.Nil(t, sc.input)
+		assert.False(t, sc.staticMetadata)
+		assert.Nil(t, sc.reqMD)
+	})
+
+	t.Run("invalid data fails every call", func(t *testing.T) {
+		sc, err := newScenarioCall(&Call{
+			Method: md,
+			Data:   map[string]interface{}{"unknown": "bob"},
+		})
+		assert.NoError(t, err)
+		assert.False(t, sc.staticData)
+		assert.True(t, sc.staticMetadata)
+		assert.Nil(t, sc.reqMD)
+	})
+}
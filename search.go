@@ -0,0 +1,240 @@
+package ghz
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+// SearchOptions configures the saturation search
+type SearchOptions struct {
+	// What is stepped: "qps" for the open-loop rate or "c" for the concurrency
+	By string `json:"by"`
+
+	// The range of the load to search
+	Min int `json:"min"`
+	Max int `json:"max"`
+
+	// How long each load step runs for
+	StepDuration time.Duration `json:"stepDuration"`
+
+	// The search stops once the passing and failing loads are within this
+	// distance. Defaults to 1% of Max.
+	Resolution int `json:"resolution,omitempty"`
+
+	// The service level objective every step is checked against
+	SLO []Threshold `json:"slo"`
+}
+
+// SearchStep holds the results of a single load step of the search
+type SearchStep struct {
+	Load      int               `json:"load"`
+	Count     uint64            `json:"count"`
+	Rps       float64           `json:"rps"`
+	ErrorRate float64           `json:"errorRate"`
+	Pass      bool              `json:"pass"`
+	Results   []ThresholdResult `json:"results"`
+}
+
+// SearchReport holds the data of a saturation search
+type SearchReport struct {
+	Options *Options       `json:"options,omitempty"`
+	Search  *SearchOptions `json:"search"`
+	Date    time.Time      `json:"date"`
+
+	Steps []SearchStep `json:"steps"`
+
+	// Whether any load within the range met the SLO
+	Found bool `json:"found"`
+
+	// The highest load that met the SLO
+	Max int `json:"max"`
+}
+
+// Searcher steps the offered load against the target to find the highest
+// load that still meets the service level objective
+type Searcher struct {
+	mtd     *desc.MethodDescriptor
+	options *Options
+	search  *SearchOptions
+
+	mu      sync.Mutex
+	current *Requester
+	stopped bool
+}
+
+// NewSearcher creates a new Searcher
+func NewSearcher(mtd *desc.MethodDescriptor, o *Options, s *SearchOptions) (*Searcher, error) {
+	if s.By != "qps" && s.By != "c" {
+		return nil, fmt.Errorf("search by must be \"qps\" or \"c\", got %q", s.By)
+	}
+
+	if s.Min < 1 || s.Max <= s.Min {
+		return nil, fmt.Errorf("invalid search range %d-%d", s.Min, s.Max)
+	}
+
+	if s.StepDuration <= 0 {
+		return nil, errors.New("search step duration must be positive")
+	}
+
+	if len(s.SLO) == 0 {
+		return nil, errors.New("search SLO is required")
+	}
+
+	if o.Record != nil {
+		return nil, errors.New("recording calls cannot be used with search")
+	}
+
+	if s.Resolution <= 0 {
+		s.Resolution = s.Max / 100
+		if s.Resolution < 1 {
+			s.Resolution = 1
+		}
+	}
+
+	return &Searcher{mtd: mtd, options: o, search: s}, nil
+}
+
+// Run runs the search and returns a report of every step
+// It blocks until the search is done.
+func (s *Searcher) Run() (*SearchReport, error) {
+	rep := &SearchReport{Options: s.options, Search: s.search, Date: time.Now()}
+
+	lo, hi := s.search.Min, s.search.Max
+
+	step, err := s.runStep(lo)
+	if err != nil || step == nil {
+		return rep, err
+	}
+	rep.Steps = append(rep.Steps, *step)
+	if !step.Pass {
+		return rep, nil
+	}
+	rep.Found, rep.Max = true, lo
+
+	step, err = s.runStep(hi)
+	if err != nil || step == nil {
+		return rep, err
+	}
+	rep.Steps = append(rep.Steps, *step)
+	if step.Pass {
+		rep.Max = hi
+		return rep, nil
+	}
+
+	for hi-lo > s.search.Resolution {
+		mid := lo + (hi-lo)/2
+		step, err = s.runStep(mid)
+		if err != nil || step == nil {
+			return rep, err
+		}
+		rep.Steps = append(rep.Steps, *step)
+
+		if step.Pass {
+			lo = mid
+			rep.Max = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return rep, nil
+}
+
+// Stop stops the search after the currently running step
+func (s *Searcher) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	if s.current != nil {
+		s.current.Stop()
+	}
+}
+
+// runStep runs the load for a single step of the search.
+// It returns nil if the search was stopped.
+func (s *Searcher) runStep(load int) (*SearchStep, error) {
+	o := *s.options
+	o.N = math.MaxInt32
+	o.Stages = nil
+	o.MetricsLinger = 0
+	o.Percentiles = sloPercentiles(o.Percentiles, s.search.SLO)
+	if s.search.By == "qps" {
+		o.QPS = load
+		o.OpenLoop = true
+		// the calls in flight are not capped, as calls dropped at the cap
+		// would measure the client: a saturated server shows in the
+		// latency and errors of the step instead
+		o.MaxInFlight = math.MaxInt32
+	} else {
+		o.C = load
+	}
+
+	reqr, err := New(s.mtd, &o)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.current = reqr
+	s.mu.Unlock()
+
+	timer := time.AfterFunc(s.search.StepDuration, reqr.Stop)
+	defer timer.Stop()
+
+	report, err := reqr.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.current = nil
+	stopped := s.stopped
+	s.mu.Unlock()
+
+	if stopped {
+		return nil, nil
+	}
+
+	step := &SearchStep{
+		Load:      load,
+		Count:     report.Count,
+		Rps:       report.Rps,
+		ErrorRate: errorRate(report),
+		Results:   EvaluateThresholds(s.search.SLO, report),
+		Pass:      true,
+	}
+
+	for _, r := range step.Results {
+		if !r.Pass {
+			step.Pass = false
+		}
+	}
+
+	return step, nil
+}
+
+// sloPercentiles returns the percentiles, or the default ones, with the ones
+// the SLO is on added so that they are in the report
+func sloPercentiles(pctls []float64, slo []Threshold) []float64 {
+	if len(pctls) == 0 {
+		pctls = defaultPercentiles
+	}
+
+	res := append([]float64(nil), pctls...)
+	for i := range slo {
+		if p, ok := slo[i].percentile(); ok {
+			res = addPercentile(res, p)
+		}
+	}
+	return res
+}
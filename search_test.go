@@ -0,0 +1,118 @@
+package ghz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tab1293/ghz/protodesc"
+)
+
+func TestSearcher(t *testing.T) {
+	_, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter.SayHello", "./testdata/greeter.proto", []string{})
+
+	data := make(map[string]interface{})
+	data["name"] = "bob"
+
+	options := &Options{
+		Host:        localhost,
+		C:           2,
+		Timeout:     20,
+		DialTimtout: 20,
+		Data:        data,
+		Insecure:    true,
+	}
+
+	t.Run("invalid options", func(t *testing.T) {
+		slo, _ := ParseThresholds("p99<1s")
+
+		var tests = []struct {
+			name string
+			in   SearchOptions
+		}{
+			{"by", SearchOptions{By: "n", Min: 1, Max: 10, StepDuration: time.Second, SLO: slo}},
+			{"range", SearchOptions{By: "c", Min: 10, Max: 10, StepDuration: time.Second, SLO: slo}},
+			{"step", SearchOptions{By: "c", Min: 1, Max: 10, SLO: slo}},
+			{"slo", SearchOptions{By: "c", Min: 1, Max: 10, StepDuration: time.Second}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				searcher, err := NewSearcher(md, options, &tt.in)
+				assert.Error(t, err)
+				assert.Nil(t, searcher)
+			})
+		}
+	})
+
+	t.Run("whole range passes", func(t *testing.T) {
+		slo, _ := ParseThresholds("p99<1s,errors<1%")
+		searcher, err := NewSearcher(md, options, &SearchOptions{
+			By:           "c",
+			Min:          1,
+			Max:          4,
+			StepDuration: 200 * time.Millisecond,
+			SLO:          slo,
+		})
+		assert.NoError(t, err)
+
+		report, err := searcher.Run()
+		assert.NoError(t, err)
+		assert.True(t, report.Found)
+		assert.Equal(t, 4, report.Max)
+		assert.Len(t, report.Steps, 2)
+		for _, step := range report.Steps {
+			assert.True(t, step.Pass)
+			assert.True(t, step.Count > 0)
+			assert.Len(t, step.Results, 2)
+		}
+	})
+
+	t.Run("slo percentile", func(t *testing.T) {
+		slo, _ := ParseThresholds("p99.9<1s")
+		searcher, err := NewSearcher(md, options, &SearchOptions{
+			By:           "c",
+			Min:          1,
+			Max:          2,
+			StepDuration: 200 * time.Millisecond,
+			SLO:          slo,
+		})
+		assert.NoError(t, err)
+
+		report, err := searcher.Run()
+		assert.NoError(t, err)
+		assert.True(t, report.Found)
+		for _, step := range report.Steps {
+			assert.True(t, step.Pass)
+			assert.Empty(t, step.Results[0].Error)
+		}
+	})
+
+	t.Run("minimum fails", func(t *testing.T) {
+		slo, _ := ParseThresholds("rps>100000000")
+		searcher, err := NewSearcher(md, options, &SearchOptions{
+			By:           "qps",
+			Min:          10,
+			Max:          100,
+			StepDuration: 200 * time.Millisecond,
+			SLO:          slo,
+		})
+		assert.NoError(t, err)
+
+		report, err := searcher.Run()
+		assert.NoError(t, err)
+		assert.False(t, report.Found)
+		assert.Len(t, report.Steps, 1)
+		assert.Equal(t, 10, report.Steps[0].Load)
+		assert.False(t, report.Steps[0].Pass)
+	})
+}
@@ -0,0 +1,136 @@
+package ghz
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Stage describes one step of a multi-stage load profile.
+// The rate and concurrency are linearly interpolated from their From values
+// at the start of the stage to the target values at the end of the stage.
+// It has the same JSON form as the stages of the config file.
+type Stage struct {
+	Duration time.Duration `json:"duration"`
+
+	// QPS at the start of the stage.
+	// Defaults to the QPS the previous stage ended with.
+	FromQPS int `json:"fromQ,omitempty"`
+
+	// QPS at the end of the stage. 0 means no rate limit,
+	// unless FromQPS is set for a ramp down to 0.
+	QPS int `json:"q,omitempty"`
+
+	// Concurrency at the start of the stage.
+	// Defaults to the concurrency the previous stage ended with.
+	FromC int `json:"fromC,omitempty"`
+
+	// Concurrency at the end of the stage. For rate limited stages this is the
+	// maximum number of in-flight calls, otherwise the number of workers.
+	// Defaults to the concurrency of the previous stage, or to the C option
+	// for the first stage.
+	C int `json:"c,omitempty"`
+}
+
+// rate returns the interpolated QPS after elapsed time into the stage
+func (s *Stage) rate(elapsed time.Duration) float64 {
+	return interpolate(s.FromQPS, s.QPS, elapsed, s.Duration)
+}
+
+// concurrency returns the interpolated concurrency after elapsed time into the stage
+func (s *Stage) concurrency(elapsed time.Duration) int {
+	c := interpolate(s.FromC, s.C, elapsed, s.Duration)
+	if c < 1 {
+		return 1
+	}
+	return int(c + 0.5)
+}
+
+// isRateLimited returns whether the calls of the stage are paced by a rate
+func (s *Stage) isRateLimited() bool {
+	return s.FromQPS > 0 || s.QPS > 0
+}
+
+// normalizeStages validates the stages and fills in the defaults for the
+// starting values from the preceding stage and the concurrency
+func normalizeStages(stages []Stage, c int) ([]Stage, error) {
+	res := make([]Stage, len(stages))
+	prevQPS, prevC := 0, c
+	for i, s := range stages {
+		if s.Duration <= 0 {
+			return nil, fmt.Errorf("stage %d: duration must be positive", i+1)
+		}
+
+		if s.FromQPS < 0 || s.QPS < 0 || s.FromC < 0 || s.C < 0 {
+			return nil, fmt.Errorf("stage %d: qps and concurrency must not be negative", i+1)
+		}
+
+		if s.C == 0 {
+			s.C = prevC
+		}
+
+		if s.FromC == 0 {
+			s.FromC = prevC
+			if i == 0 {
+				s.FromC = s.C
+			}
+		}
+
+		if s.FromQPS == 0 && s.QPS > 0 {
+			s.FromQPS = prevQPS
+			if s.FromQPS == 0 {
+				s.FromQPS = s.QPS
+			}
+		}
+
+		prevQPS, prevC = s.QPS, s.C
+		res[i] = s
+	}
+
+	return res, nil
+}
+
+// UnmarshalJSON parses the duration of the stage from a string such as 30s
+func (s *Stage) UnmarshalJSON(data []byte) error {
+	type Alias Stage
+	aux := &struct {
+		Duration string `json:"duration"`
+		*Alias
+	}{
+		Alias: (*Alias)(s),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	d, err := time.ParseDuration(aux.Duration)
+	if err != nil {
+		return fmt.Errorf("stage duration: %v", err)
+	}
+
+	s.Duration = d
+	return nil
+}
+
+// MarshalJSON formats the duration of the stage as a string such as 30s
+func (s Stage) MarshalJSON() ([]byte, error) {
+	type Alias Stage
+	return json.Marshal(&struct {
+		Duration string `json:"duration"`
+		*Alias
+	}{
+		Duration: s.Duration.String(),
+		Alias:    (*Alias)(&s),
+	})
+}
+
+func interpolate(from, to int, elapsed, duration time.Duration) float64 {
+	if elapsed >= duration {
+		return float64(to)
+	}
+	if elapsed <= 0 {
+		return float64(from)
+	}
+	p := float64(elapsed) / float64(duration)
+	return float64(from) + float64(to-from)*p
+}
@@ -0,0 +1,99 @@
+package ghz
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStage_normalizeStages(t *testing.T) {
+	t.Run("defaults from previous stage", func(t *testing.T) {
+		stages, err := normalizeStages([]Stage{
+			{Duration: 30 * time.Second, FromQPS: 10, QPS: 500},
+			{Duration: 5 * time.Minute, QPS: 500, C: 20},
+			{Duration: 30 * time.Second, QPS: 10},
+			{Duration: time.Minute, FromC: 5, C: 10},
+		}, 50)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Stage{
+			{Duration: 30 * time.Second, FromQPS: 10, QPS: 500, FromC: 50, C: 50},
+			{Duration: 5 * time.Minute, FromQPS: 500, QPS: 500, FromC: 50, C: 20},
+			{Duration: 30 * time.Second, FromQPS: 500, QPS: 10, FromC: 20, C: 20},
+			{Duration: time.Minute, FromC: 5, C: 10},
+		}, stages)
+	})
+
+	t.Run("ramp down to 0", func(t *testing.T) {
+		stages, err := normalizeStages([]Stage{
+			{Duration: time.Minute, QPS: 500},
+			{Duration: 30 * time.Second, FromQPS: 500},
+		}, 50)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Stage{
+			{Duration: time.Minute, FromQPS: 500, QPS: 500, FromC: 50, C: 50},
+			{Duration: 30 * time.Second, FromQPS: 500, FromC: 50, C: 50},
+		}, stages)
+
+		assert.True(t, stages[1].isRateLimited())
+		assert.Equal(t, 250.0, stages[1].rate(15*time.Second))
+		assert.Equal(t, 0.0, stages[1].rate(30*time.Second))
+	})
+
+	t.Run("constant first stage", func(t *testing.T) {
+		stages, err := normalizeStages([]Stage{{Duration: time.Second, QPS: 100, C: 5}}, 50)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Stage{{Duration: time.Second, FromQPS: 100, QPS: 100, FromC: 5, C: 5}}, stages)
+	})
+
+	var tests = []struct {
+		name  string
+		stage Stage
+	}{
+		{"missing duration", Stage{QPS: 10}},
+		{"negative qps", Stage{Duration: time.Second, QPS: -1}},
+		{"negative concurrency", Stage{Duration: time.Second, C: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := normalizeStages([]Stage{tt.stage}, 50)
+			assert.Error(t, err)
+			assert.Nil(t, stages)
+		})
+	}
+}
+
+func TestStage_Interpolation(t *testing.T) {
+	s := &Stage{Duration: 10 * time.Second, FromQPS: 10, QPS: 110, FromC: 1, C: 11}
+
+	assert.Equal(t, 10.0, s.rate(0))
+	assert.Equal(t, 60.0, s.rate(5*time.Second))
+	assert.Equal(t, 110.0, s.rate(10*time.Second))
+	assert.Equal(t, 110.0, s.rate(time.Minute))
+
+	assert.Equal(t, 1, s.concurrency(0))
+	assert.Equal(t, 6, s.concurrency(5*time.Second))
+	assert.Equal(t, 11, s.concurrency(10*time.Second))
+
+	assert.True(t, s.isRateLimited())
+	assert.False(t, (&Stage{Duration: time.Second, C: 10}).isRateLimited())
+}
+
+func TestStage_JSON(t *testing.T) {
+	s := Stage{Duration: 30 * time.Second, FromQPS: 500, C: 20}
+
+	b, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"duration":"30s","fromQ":500,"c":20}`, string(b))
+
+	var res Stage
+	assert.NoError(t, json.Unmarshal([]byte(`{"duration":"30s","fromQ":500,"c":20}`), &res))
+	assert.Equal(t, s, res)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"duration":"soon"}`), &res))
+}
@@ -8,14 +8,45 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// callInfo is attached to the context of each call so the stats handler
+// can relate the end of the RPC back to how the call was scheduled
+type callInfo struct {
+	// the time the call was supposed to be sent according to the rate limit
+	intendedStart time.Time
+
+	// 1-based index of the load stage, 0 when not running stages
+	stage int
+
+	// 1-based index of the call of the scenario or the step of the sequence
+	call int
+
+	// 1-based index of the worker making the call,
+	// 0 when the call is not made by a worker such as in open loop mode
+	worker int
+}
+
+type callInfoKey struct{}
+
 // StatsHandler is for gRPC stats
 type statsHandler struct {
 	results chan *callResult
+
+	// the live metrics, nil when they are not served
+	metrics *metrics
 }
 
-// HandleConn handle the connection
+// HandleConn counts the connections open in the metrics
 func (c *statsHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {
-	// no-op
+	if c.metrics == nil {
+		return
+	}
+
+	switch cs.(type) {
+	case *stats.ConnBegin:
+		c.metrics.connBegin()
+	case *stats.ConnEnd:
+		c.metrics.connEnd()
+	}
 }
 
 // TagConn exists to satisfy gRPC stats.Handler.
@@ -27,22 +58,45 @@ func (c *statsHandler) TagConn(ctx context.Context, cti *stats.ConnTagInfo) cont
 // HandleRPC implements per-RPC tracing and stats instrumentation.
 func (c *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 	switch rs.(type) {
+	case *stats.Begin:
+		if c.metrics != nil {
+			c.metrics.begin()
+		}
 	case *stats.End:
 		rpcStats := rs.(*stats.End)
 		end := time.Now()
 		duration := end.Sub(rpcStats.BeginTime)
 
-		var st string
-		s, ok := status.FromError(rpcStats.Error)
-		if ok {
-			st = s.Code().String()
+		// errors other than a status from the server are reported as Unknown
+		st := status.Convert(rpcStats.Error).Code().String()
+
+		res := &callResult{err: rpcStats.Error, status: st, duration: duration, end: end}
+		if c.metrics != nil {
+			// the result is recorded by the reporter, once the assertions are checked
+			c.metrics.end()
+			res.method, _ = ctx.Value(metricsMethodKey{}).(string)
+		}
+		if info, ok := ctx.Value(callInfoKey{}).(*callInfo); ok {
+			if !info.intendedStart.IsZero() {
+				res.responseTime = end.Sub(info.intendedStart)
+			}
+			res.stage = info.stage
+			res.call = info.call
+		}
+
+		// the result of a call with assertions is reported once they are checked
+		if held, ok := ctx.Value(heldResultKey{}).(*heldResult); ok && held.hold(res) {
+			return
 		}
 
-		c.results <- &callResult{rpcStats.Error, st, duration}
+		c.results <- res
 	}
 }
 
 // TagRPC implements per-RPC context management.
 func (c *statsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	if c.metrics != nil {
+		return context.WithValue(ctx, metricsMethodKey{}, metricsMethod(info.FullMethodName))
+	}
 	return ctx
 }
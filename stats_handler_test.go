@@ -32,7 +32,7 @@ func TestStatsHandler(t *testing.T) {
 		done <- true
 	}()
 
-	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithStatsHandler(&statsHandler{rChan}))
+	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithStatsHandler(&statsHandler{results: rChan}))
 
 	if err != nil {
 		assert.FailNow(t, err.Error())
@@ -57,3 +57,41 @@ func TestStatsHandler(t *testing.T) {
 	assert.NotNil(t, results[0])
 	assert.NotNil(t, results[1])
 }
+
+func TestStatsHandler_IntendedStart(t *testing.T) {
+	_, s, err := startServer(false)
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer s.Stop()
+
+	rChan := make(chan *callResult, 2)
+
+	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithStatsHandler(&statsHandler{results: rChan}))
+
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	c := helloworld.NewGreeterClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err = c.SayHello(ctx, &helloworld.HelloRequest{Name: "Bob"})
+	assert.NoError(t, err)
+
+	intended := time.Now().Add(-100 * time.Millisecond)
+	pacedCtx := context.WithValue(ctx, callInfoKey{}, &callInfo{intendedStart: intended})
+	_, err = c.SayHello(pacedCtx, &helloworld.HelloRequest{Name: "Kate"})
+	assert.NoError(t, err)
+
+	res := <-rChan
+	assert.Zero(t, res.responseTime)
+
+	res = <-rChan
+	assert.True(t, res.responseTime >= 100*time.Millisecond)
+	assert.True(t, res.responseTime > res.duration)
+}
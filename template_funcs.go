@@ -0,0 +1,155 @@
+package ghz
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const randomStringLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// templateWorker holds the state of the template functions of a worker.
+// Every worker has its own random source so that with a seed the values of
+// each worker are the same from run to run, however the calls interleave.
+type templateWorker struct {
+	id int
+
+	// number of requests made by the worker, accessed atomically
+	requests int64
+
+	rand  *rand.Rand
+	funcs template.FuncMap
+}
+
+func newTemplateWorker(id int, seed int64) *templateWorker {
+	r := rand.New(&lockedSource{src: rand.NewSource(seed + int64(id))})
+	return &templateWorker{id: id, rand: r, funcs: templateFuncs(r)}
+}
+
+// defaultTemplateWorker provides the functions of the templates
+// that are not executed for a worker, and is used for parsing
+var defaultTemplateWorker = newTemplateWorker(0, time.Now().UnixNano())
+
+// templateFuncs returns the functions available to the call templates
+// with the random values taken from r
+func templateFuncs(r *rand.Rand) template.FuncMap {
+	return template.FuncMap{
+		// a random integer between min and max inclusive
+		"randomInt": func(min, max int) (int, error) {
+			if max < min {
+				return 0, fmt.Errorf("randomInt: max %d is less than min %d", max, min)
+			}
+			return min + r.Intn(max-min+1), nil
+		},
+
+		// a random string of n letters and digits
+		"randomString": func(n int) string {
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = randomStringLetters[r.Intn(len(randomStringLetters))]
+			}
+			return string(b)
+		},
+
+		// one of the given values at random
+		"randomChoice": func(choices ...interface{}) (interface{}, error) {
+			if len(choices) == 0 {
+				return nil, fmt.Errorf("randomChoice: no choices")
+			}
+			return choices[r.Intn(len(choices))], nil
+		},
+
+		// a random version 4 UUID
+		"uuid": func() string {
+			// Rand.Read keeps state outside of the source, so the bytes are
+			// taken from Uint64 which only goes through the locked source
+			var u [16]byte
+			binary.BigEndian.PutUint64(u[:8], r.Uint64())
+			binary.BigEndian.PutUint64(u[8:], r.Uint64())
+			u[6] = (u[6] & 0x0f) | 0x40
+			u[8] = (u[8] & 0x3f) | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+		},
+
+		"base64Encode": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"base64Decode": func(s string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(s)
+			return string(b), err
+		},
+
+		// times as RFC 3339 strings: the current time, the time t shifted by
+		// a duration such as "-1h30m" and the time t in another layout
+		"now": func(layout ...string) (string, error) {
+			if len(layout) > 1 {
+				return "", fmt.Errorf("now: expected at most one layout, got %d", len(layout))
+			}
+			if len(layout) == 1 {
+				return time.Now().Format(layout[0]), nil
+			}
+			return time.Now().Format(time.RFC3339Nano), nil
+		},
+		"timeAdd": func(d string, t string) (string, error) {
+			dur, err := time.ParseDuration(d)
+			if err != nil {
+				return "", err
+			}
+			tm, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return "", err
+			}
+			return tm.Add(dur).Format(time.RFC3339Nano), nil
+		},
+		"timeFormat": func(layout string, t string) (string, error) {
+			tm, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return "", err
+			}
+			return tm.Format(layout), nil
+		},
+
+		// hex encoded hashes
+		"md5": func(s string) string {
+			h := md5.Sum([]byte(s))
+			return hex.EncodeToString(h[:])
+		},
+		"sha1": func(s string) string {
+			h := sha1.Sum([]byte(s))
+			return hex.EncodeToString(h[:])
+		},
+		"sha256": func(s string) string {
+			h := sha256.Sum256([]byte(s))
+			return hex.EncodeToString(h[:])
+		},
+	}
+}
+
+// lockedSource is a random source safe for concurrent use, as the worker 0
+// is shared by all the calls not made by a worker, such as the open-loop calls
+// and the calls of rate limited stages, and a worker can briefly overlap with
+// its replacement within a stage
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
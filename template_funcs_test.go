@@ -0,0 +1,132 @@
+package ghz
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tab1293/ghz/protodesc"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	md, err := protodesc.GetMethodDescFromProto("helloworld.Greeter/SayHello", "./testdata/greeter.proto", []string{})
+	assert.NoError(t, err)
+	assert.NotNil(t, md)
+
+	execute := func(w *templateWorker, text string) string {
+		ctd := newCallTemplateData(md, 1)
+		ctd.worker = w
+		out, err := parseCallTemplate(text).execute(ctd)
+		assert.NoError(t, err)
+		return string(out)
+	}
+
+	executeErr := func(w *templateWorker, text string) error {
+		ctd := newCallTemplateData(md, 1)
+		ctd.worker = w
+		_, err := parseCallTemplate(text).execute(ctd)
+		return err
+	}
+
+	w := newTemplateWorker(1, 42)
+
+	var tests = []struct {
+		name     string
+		in       string
+		expected string
+	}{
+		{"base64Encode", `{{base64Encode "bob"}}`, "Ym9i"},
+		{"base64Decode", `{{base64Decode "Ym9i"}}`, "bob"},
+		{"md5", `{{md5 "bob"}}`, "9f9d51bc70ef21ca5c14f307980a29d8"},
+		{"sha1", `{{sha1 "bob"}}`, "48181acd22b3edaebc8a447868a7df7ce629920a"},
+		{"sha256", `{{sha256 "bob"}}`, "81b637d8fcd2c6da6359e6963113a1170de795e4b725b84d1e0b4cfd9ec58ce9"},
+		{"timeAdd", `{{timeAdd "-1h" "2019-03-01T10:00:00.5Z"}}`, "2019-03-01T09:00:00.5Z"},
+		{"timeAdd offset", `{{timeAdd "36h" "2019-03-01T10:00:00+02:00"}}`, "2019-03-02T22:00:00+02:00"},
+		{"timeFormat", `{{timeFormat "2006-01-02" "2019-03-01T10:00:00Z"}}`, "2019-03-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, execute(w, tt.in))
+		})
+	}
+
+	t.Run("randomInt", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			seen[execute(w, `{{randomInt 1 3}}`)] = true
+		}
+		assert.Equal(t, map[string]bool{"1": true, "2": true, "3": true}, seen)
+	})
+
+	t.Run("randomString", func(t *testing.T) {
+		assert.Regexp(t, regexp.MustCompile(`^[a-zA-Z0-9]{12}$`), execute(w, `{{randomString 12}}`))
+	})
+
+	t.Run("randomChoice", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			seen[execute(w, `{{randomChoice "a" "b"}}`)] = true
+		}
+		assert.Equal(t, map[string]bool{"a": true, "b": true}, seen)
+	})
+
+	t.Run("uuid", func(t *testing.T) {
+		uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+		first := execute(w, `{{uuid}}`)
+		assert.Regexp(t, uuid, first)
+		assert.NotEqual(t, first, execute(w, `{{uuid}}`))
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// the calls not made by a worker all share the worker 0
+		shared := newTemplateWorker(0, 7)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					execute(shared, `{{uuid}} {{randomInt 0 10}} {{randomString 8}}`)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("now", func(t *testing.T) {
+		before := time.Now()
+		actual, err := time.Parse(time.RFC3339Nano, execute(w, `{{now}}`))
+		assert.NoError(t, err)
+		assert.False(t, actual.Before(before))
+		assert.False(t, actual.After(time.Now()))
+
+		assert.Equal(t, time.Now().Format("2006-01-02"), execute(w, `{{now "2006-01-02"}}`))
+
+		yesterday := time.Now().Add(-24 * time.Hour).Format("2006-01-02")
+		assert.Equal(t, yesterday, execute(w, `{{now | timeAdd "-24h" | timeFormat "2006-01-02"}}`))
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		assert.Error(t, executeErr(w, `{{randomInt 3 1}}`))
+		assert.Error(t, executeErr(w, `{{timeAdd "soon" now}}`))
+		assert.Error(t, executeErr(w, `{{timeAdd "1h" "yesterday"}}`))
+		assert.Error(t, executeErr(w, `{{timeFormat "2006" "yesterday"}}`))
+		assert.Error(t, executeErr(w, `{{now "2006" "01"}}`))
+		assert.Error(t, executeErr(w, `{{ base64Decode "!!" }}`))
+	})
+
+	t.Run("seed", func(t *testing.T) {
+		text := `{{uuid}} {{randomInt 0 1000000}} {{randomString 8}}`
+		a, b, other := newTemplateWorker(1, 7), newTemplateWorker(1, 7), newTemplateWorker(2, 7)
+
+		for i := 0; i < 5; i++ {
+			va := execute(a, text)
+			assert.Equal(t, va, execute(b, text))
+			assert.NotEqual(t, va, execute(other, text))
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package ghz
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Threshold is a pass / fail condition on a metric of the report,
+// for example p99<50ms, errors<0.1% or rps>500.
+type Threshold struct {
+	// The metric: a latency percentile such as p99, average, fastest or
+	// slowest, the error rate errors or the requests per second rps
+	Metric string `json:"metric"`
+
+	// The comparison operator: <, <=, > or >=
+	Op string `json:"op"`
+
+	// The value to compare to, in milliseconds for latencies and
+	// as a percentage for the error rate
+	Value float64 `json:"value"`
+
+	// The original expression
+	Expr string `json:"expr"`
+}
+
+// ThresholdResult holds the outcome of evaluating a threshold against a report
+type ThresholdResult struct {
+	Threshold Threshold `json:"threshold"`
+	Actual    float64   `json:"actual"`
+	Pass      bool      `json:"pass"`
+	Error     string    `json:"error,omitempty"`
+}
+
+var thresholdOps = []string{"<=", ">=", "<", ">"}
+
+// ParseThresholds parses a comma separated list of threshold expressions
+func ParseThresholds(in string) ([]Threshold, error) {
+	var res []Threshold
+	for _, expr := range strings.Split(in, ",") {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+
+		t, err := ParseThreshold(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, *t)
+	}
+
+	return res, nil
+}
+
+// ParseThreshold parses a single threshold expression such as p99<50ms
+func ParseThreshold(expr string) (*Threshold, error) {
+	for _, op := range thresholdOps {
+		pos := strings.Index(expr, op)
+		if pos < 0 {
+			continue
+		}
+
+		metric := strings.ToLower(strings.TrimSpace(expr[:pos]))
+		valueStr := strings.TrimSpace(expr[pos+len(op):])
+
+		value, err := parseThresholdValue(metric, valueStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q: %v", expr, err)
+		}
+
+		return &Threshold{Metric: metric, Op: op, Value: value, Expr: expr}, nil
+	}
+
+	return nil, fmt.Errorf("invalid threshold %q: expected <metric><op><value>", expr)
+}
+
+func parseThresholdValue(metric, value string) (float64, error) {
+	if p, ok := parsePercentileMetric(metric); ok && !isLatencyMetric(metric) {
+		return 0, fmt.Errorf("percentile %v must be greater than 0 and at most 100", p)
+	}
+
+	switch {
+	case isLatencyMetric(metric):
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+		return d.Seconds() * 1000, nil
+	case metric == "errors":
+		return strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	case metric == "rps":
+		return strconv.ParseFloat(value, 64)
+	}
+
+	return 0, fmt.Errorf("unknown metric %q", metric)
+}
+
+func isLatencyMetric(metric string) bool {
+	switch metric {
+	case "average", "fastest", "slowest":
+		return true
+	}
+
+	p, ok := parsePercentileMetric(metric)
+	return ok && p > 0 && p <= 100
+}
+
+// parsePercentileMetric returns the percentile of a metric such as p99.9
+func parsePercentileMetric(metric string) (float64, bool) {
+	if !strings.HasPrefix(metric, "p") {
+		return 0, false
+	}
+
+	p, err := strconv.ParseFloat(metric[1:], 64)
+	return p, err == nil
+}
+
+// IsLatency returns whether the threshold is on a latency metric
+func (t *Threshold) IsLatency() bool {
+	return isLatencyMetric(t.Metric)
+}
+
+// Evaluate checks the threshold against the report
+func (t *Threshold) Evaluate(r *Report) ThresholdResult {
+	res := ThresholdResult{Threshold: *t}
+
+	actual, err := t.actual(r)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Actual = actual
+
+	switch t.Op {
+	case "<":
+		res.Pass = actual < t.Value
+	case "<=":
+		res.Pass = actual <= t.Value
+	case ">":
+		res.Pass = actual > t.Value
+	case ">=":
+		res.Pass = actual >= t.Value
+	}
+
+	return res
+}
+
+func (t *Threshold) actual(r *Report) (float64, error) {
+	toMs := func(d time.Duration) float64 {
+		return d.Seconds() * 1000
+	}
+
+	switch t.Metric {
+	case "average":
+		return toMs(r.Average), nil
+	case "fastest":
+		return toMs(r.Fastest), nil
+	case "slowest":
+		return toMs(r.Slowest), nil
+	case "rps":
+		return r.Rps, nil
+	case "errors":
+		return errorRate(r), nil
+	}
+
+	pctl, ok := t.percentile()
+	if !ok {
+		return 0, fmt.Errorf("unknown metric %q", t.Metric)
+	}
+
+	for _, ld := range r.LatencyDistribution {
+		if ld.Percentage == pctl {
+			return toMs(ld.Latency), nil
+		}
+	}
+
+	return 0, fmt.Errorf("no %s latency in the report", t.Metric)
+}
+
+// percentile returns the latency percentile the threshold is on, if any
+func (t *Threshold) percentile() (float64, bool) {
+	if !strings.HasPrefix(t.Metric, "p") {
+		return 0, false
+	}
+
+	pctl, err := strconv.ParseFloat(t.Metric[1:], 64)
+	return pctl, err == nil
+}
+
+// FailedThresholds returns the thresholds of the report that did not pass
+func (r *Report) FailedThresholds() []ThresholdResult {
+	var res []ThresholdResult
+	for _, t := range r.Thresholds {
+		if !t.Pass {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// EvaluateThresholds checks all the thresholds against the report
+func EvaluateThresholds(thresholds []Threshold, r *Report) []ThresholdResult {
+	res := make([]ThresholdResult, len(thresholds))
+	for i := range thresholds {
+		res[i] = thresholds[i].Evaluate(r)
+	}
+	return res
+}
+
+// errorRate returns the percentage of the calls that failed
+func errorRate(r *Report) float64 {
+	if r.Count == 0 {
+		return 0
+	}
+
+	errCount := 0
+	for _, num := range r.ErrorDist {
+		errCount += num
+	}
+	return float64(errCount) / float64(r.Count) * 100
+}
@@ -0,0 +1,127 @@
+package ghz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreshold_Parse(t *testing.T) {
+	var tests = []struct {
+		name     string
+		in       string
+		expected []Threshold
+		err      bool
+	}{
+		{"empty", "", nil, false},
+		{"latency", "p99<50ms",
+			[]Threshold{{Metric: "p99", Op: "<", Value: 50, Expr: "p99<50ms"}}, false},
+		{"multiple", "p95 <= 1s, errors<0.1%,rps>=500",
+			[]Threshold{
+				{Metric: "p95", Op: "<=", Value: 1000, Expr: "p95 <= 1s"},
+				{Metric: "errors", Op: "<", Value: 0.1, Expr: "errors<0.1%"},
+				{Metric: "rps", Op: ">=", Value: 500, Expr: "rps>=500"},
+			}, false},
+		{"average", "average>2ms",
+			[]Threshold{{Metric: "average", Op: ">", Value: 2, Expr: "average>2ms"}}, false},
+		{"no op", "p99=50ms", nil, true},
+		{"unknown metric", "foo<5", nil, true},
+		{"p0", "p0<5ms", nil, true},
+		{"p150", "p150<5ms", nil, true},
+		{"p-5", "p-5<5ms", nil, true},
+		{"pNaN", "pNaN<5ms", nil, true},
+		{"p100", "p100<5ms",
+			[]Threshold{{Metric: "p100", Op: "<", Value: 5, Expr: "p100<5ms"}}, false},
+		{"bad duration", "p99<50", nil, true},
+		{"bad rate", "errors<x%", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseThresholds(tt.in)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestThreshold_Evaluate(t *testing.T) {
+	r := &Report{
+		Count:   200,
+		Average: 5 * time.Millisecond,
+		Fastest: 1 * time.Millisecond,
+		Slowest: 20 * time.Millisecond,
+		Rps:     400,
+		ErrorDist: map[string]int{
+			"rpc error: code = Unavailable": 2,
+		},
+		LatencyDistribution: []LatencyDistribution{
+			{Percentage: 50, Latency: 4 * time.Millisecond},
+			{Percentage: 99, Latency: 15 * time.Millisecond},
+		},
+	}
+
+	var tests = []struct {
+		expr   string
+		actual float64
+		pass   bool
+		err    bool
+	}{
+		{"p99<20ms", 15, true, false},
+		{"p99<10ms", 15, false, false},
+		{"p50<=4ms", 4, true, false},
+		{"average<5ms", 5, false, false},
+		{"slowest<1s", 20, true, false},
+		{"errors<1%", 1, false, false},
+		{"errors<=1%", 1, true, false},
+		{"rps>500", 400, false, false},
+		{"p90<10ms", 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			th, err := ParseThreshold(tt.expr)
+			assert.NoError(t, err)
+
+			res := th.Evaluate(r)
+			assert.Equal(t, tt.pass, res.Pass)
+			if tt.err {
+				assert.NotEmpty(t, res.Error)
+				return
+			}
+			assert.Empty(t, res.Error)
+			assert.InDelta(t, tt.actual, res.Actual, 0.001)
+		})
+	}
+}
+
+func TestReport_FailedThresholds(t *testing.T) {
+	r := &Report{Thresholds: []ThresholdResult{
+		{Threshold: Threshold{Expr: "p99<20ms"}, Pass: true},
+		{Threshold: Threshold{Expr: "rps>500"}},
+		{Threshold: Threshold{Expr: "p90<10ms"}, Error: "no p90 latency in the report"},
+	}}
+
+	failed := r.FailedThresholds()
+	assert.Len(t, failed, 2)
+	assert.Equal(t, "rps>500", failed[0].Threshold.Expr)
+	assert.Equal(t, "p90<10ms", failed[1].Threshold.Expr)
+
+	assert.Empty(t, (&Report{}).FailedThresholds())
+}
+
+func TestReporter_thresholdPercentiles(t *testing.T) {
+	ts, err := ParseThresholds("p99.9<10ms,p50<1ms,errors<1%")
+	assert.NoError(t, err)
+
+	r := newReporter(nil, &Options{Percentiles: []float64{50, 99}, Thresholds: ts}, time.Now())
+	assert.Equal(t, []float64{50, 99, 99.9}, r.percentiles)
+
+	r = newReporter(nil, &Options{}, time.Now())
+	assert.Equal(t, defaultPercentiles, r.percentiles)
+}
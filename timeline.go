@@ -0,0 +1,167 @@
+package ghz
+
+import (
+	"time"
+)
+
+// The length of the intervals of the timeline by default
+const defaultInterval = time.Second
+
+// Interval holds the results of the calls that ended within an interval of the run
+type Interval struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Count    uint64        `json:"count"`
+	Rps      float64       `json:"rps"`
+
+	// The number of calls that failed by status code
+	Errors map[string]int `json:"errors,omitempty"`
+
+	Average             time.Duration         `json:"average"`
+	Fastest             time.Duration         `json:"fastest"`
+	Slowest             time.Duration         `json:"slowest"`
+	LatencyDistribution []LatencyDistribution `json:"latencyDistribution"`
+}
+
+// timeline accumulates the results by the interval of the run they ended in.
+// Only the latest two intervals are kept open for the calls reported out of
+// order, the earlier ones are summarized as soon as a call ends in a later
+// one, so that the memory used does not grow with the length of the run.
+type timeline struct {
+	start       time.Time
+	interval    time.Duration
+	precision   int
+	percentiles []float64
+
+	// the summarized intervals, from the start of the run
+	intervals []Interval
+
+	// the intervals still open by index
+	open map[int]*openInterval
+
+	// latency stats of the summarized intervals to reuse
+	free []*latencyStats
+}
+
+type openInterval struct {
+	lats   *latencyStats
+	errors map[string]int
+}
+
+func newTimeline(start time.Time, interval time.Duration, precision int, percentiles []float64) *timeline {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	return &timeline{
+		start:       start,
+		interval:    interval,
+		precision:   precision,
+		percentiles: percentiles,
+		open:        make(map[int]*openInterval),
+	}
+}
+
+func (t *timeline) add(res *callResult) {
+	idx := 0
+	if res.end.After(t.start) {
+		idx = int(res.end.Sub(t.start) / t.interval)
+	}
+
+	// a call reported after its interval was summarized is only counted
+	if idx < len(t.intervals) {
+		in := &t.intervals[idx]
+		in.Count++
+		if res.err != nil {
+			if in.Errors == nil {
+				in.Errors = make(map[string]int)
+			}
+			in.Errors[res.status]++
+		}
+		return
+	}
+
+	in, ok := t.open[idx]
+	if !ok {
+		in = &openInterval{lats: t.newLatencyStats(), errors: make(map[string]int)}
+		t.open[idx] = in
+	}
+
+	in.lats.add(res.duration)
+	if res.err != nil {
+		in.errors[res.status]++
+	}
+
+	for len(t.intervals) < idx-1 {
+		t.close()
+	}
+}
+
+// close summarizes the earliest interval that is not yet
+func (t *timeline) close() {
+	idx := len(t.intervals)
+	in, ok := t.open[idx]
+	if !ok {
+		// no call ended within the interval
+		in = &openInterval{lats: t.newLatencyStats()}
+	}
+	delete(t.open, idx)
+
+	res := Interval{
+		Start:               t.start.Add(time.Duration(idx) * t.interval),
+		Duration:            t.interval,
+		Count:               in.lats.count,
+		Average:             in.lats.average(),
+		Fastest:             in.lats.fastest,
+		Slowest:             in.lats.slowest,
+		LatencyDistribution: in.lats.latencies(t.percentiles),
+	}
+
+	if len(in.errors) > 0 {
+		res.Errors = in.errors
+	}
+
+	t.free = append(t.free, in.lats)
+	t.intervals = append(t.intervals, res)
+}
+
+// finish summarizes all the intervals of the run given how long it ran,
+// and returns them
+func (t *timeline) finish(total time.Duration) []Interval {
+	n := int((total + t.interval - 1) / t.interval)
+	for idx := range t.open {
+		if idx >= n {
+			n = idx + 1
+		}
+	}
+
+	for len(t.intervals) < n {
+		t.close()
+	}
+
+	for i := range t.intervals {
+		in := &t.intervals[i]
+
+		// the last interval is cut short by the end of the run
+		if end := time.Duration(i+1) * t.interval; end > total && total > time.Duration(i)*t.interval {
+			in.Duration = total - time.Duration(i)*t.interval
+		}
+
+		if in.Duration > 0 {
+			in.Rps = float64(in.Count) / in.Duration.Seconds()
+		}
+	}
+
+	return t.intervals
+}
+
+func (t *timeline) newLatencyStats() *latencyStats {
+	if n := len(t.free); n > 0 {
+		l := t.free[n-1]
+		t.free = t.free[:n-1]
+		l.reset()
+		return l
+	}
+
+	return newLatencyStats(t.precision)
+}
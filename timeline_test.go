@@ -0,0 +1,78 @@
+package ghz
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeline(t *testing.T) {
+	start := time.Now()
+	at := func(offset time.Duration) time.Time {
+		return start.Add(offset)
+	}
+
+	t.Run("intervals", func(t *testing.T) {
+		tl := newTimeline(start, time.Second, 5, []float64{50, 99})
+
+		tl.add(&callResult{status: "OK", duration: 10 * time.Millisecond, end: at(100 * time.Millisecond)})
+		tl.add(&callResult{status: "OK", duration: 30 * time.Millisecond, end: at(900 * time.Millisecond)})
+		tl.add(&callResult{err: errors.New("unavailable"), status: "Unavailable", duration: time.Millisecond,
+			end: at(1500 * time.Millisecond)})
+		tl.add(&callResult{status: "OK", duration: 500 * time.Millisecond, end: at(3200 * time.Millisecond)})
+
+		intervals := tl.finish(3500 * time.Millisecond)
+		assert.Len(t, intervals, 4)
+
+		first := intervals[0]
+		assert.Equal(t, start, first.Start)
+		assert.Equal(t, time.Second, first.Duration)
+		assert.Equal(t, uint64(2), first.Count)
+		assert.Equal(t, 2.0, first.Rps)
+		assert.Empty(t, first.Errors)
+		assert.Equal(t, 20*time.Millisecond, first.Average)
+		assert.Equal(t, 10*time.Millisecond, first.Fastest)
+		assert.Equal(t, 30*time.Millisecond, first.Slowest)
+		assert.Equal(t, []LatencyDistribution{
+			{Percentage: 50, Latency: 10 * time.Millisecond},
+			{Percentage: 99, Latency: 30 * time.Millisecond},
+		}, first.LatencyDistribution)
+
+		assert.Equal(t, uint64(1), intervals[1].Count)
+		assert.Equal(t, map[string]int{"Unavailable": 1}, intervals[1].Errors)
+
+		// no call ended within the third interval
+		assert.Equal(t, at(2*time.Second), intervals[2].Start)
+		assert.Equal(t, uint64(0), intervals[2].Count)
+		assert.Equal(t, 0.0, intervals[2].Rps)
+		assert.Len(t, intervals[2].LatencyDistribution, 2)
+		assert.Equal(t, 99.0, intervals[2].LatencyDistribution[1].Percentage)
+
+		// the last interval is cut short by the end of the run
+		assert.Equal(t, 500*time.Millisecond, intervals[3].Duration)
+		assert.Equal(t, 2.0, intervals[3].Rps)
+	})
+
+	t.Run("out of order", func(t *testing.T) {
+		tl := newTimeline(start, 100*time.Millisecond, defaultPrecision, defaultPercentiles)
+
+		tl.add(&callResult{status: "OK", duration: time.Millisecond, end: at(150 * time.Millisecond)})
+		tl.add(&callResult{status: "OK", duration: time.Millisecond, end: at(50 * time.Millisecond)})
+		tl.add(&callResult{status: "OK", duration: time.Millisecond, end: at(350 * time.Millisecond)})
+		assert.Len(t, tl.intervals, 2)
+		assert.Len(t, tl.open, 1)
+
+		// the interval has been summarized, so the call is only counted
+		tl.add(&callResult{err: errors.New("late"), status: "Unknown", duration: time.Second, end: at(120 * time.Millisecond)})
+
+		intervals := tl.finish(400 * time.Millisecond)
+		assert.Len(t, intervals, 4)
+		assert.Equal(t, uint64(1), intervals[0].Count)
+		assert.Equal(t, uint64(2), intervals[1].Count)
+		assert.Equal(t, map[string]int{"Unknown": 1}, intervals[1].Errors)
+		assert.Equal(t, time.Millisecond, intervals[1].Slowest)
+		assert.Equal(t, uint64(1), intervals[3].Count)
+	})
+}